@@ -0,0 +1,28 @@
+package sqlingo
+
+// argList collects the bound arguments of a statement in parameterized query mode.
+// A nil *argList means the values are inlined into the SQL string as literals.
+type argList struct {
+	dialect Dialect
+	values  []interface{}
+}
+
+func newArgList(db *database) *argList {
+	if db == nil || !db.parameterized {
+		return nil
+	}
+	return &argList{dialect: db.dialect}
+}
+
+// add appends a value and returns the placeholder referring to it.
+func (a *argList) add(value interface{}) string {
+	a.values = append(a.values, value)
+	return a.dialect.Placeholder(len(a.values))
+}
+
+func (a *argList) getValues() []interface{} {
+	if a == nil {
+		return nil
+	}
+	return a.values
+}
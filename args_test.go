@@ -0,0 +1,74 @@
+package sqlingo
+
+import (
+	"testing"
+	"time"
+)
+
+func newParameterizedMockDatabase(dialect Dialect) Database {
+	db := newMockDatabase()
+	db.(*database).dialect = dialect
+	db.EnableParameterizedQuery(true)
+	return db
+}
+
+func TestParameterizedQuery(t *testing.T) {
+	db := newParameterizedMockDatabase(dialectMySQL)
+	tm := time.Date(2023, 9, 6, 18, 37, 46, 0, time.UTC)
+
+	_, _ = db.Select(field1).From(Table1).Where(field1.Equals(1), field2.In("a'b", int8(2), CustomInt(3))).FetchFirst()
+	assertLastSql(t, "SELECT `field1` FROM `table1` WHERE `field1` = ? AND `field2` IN (?, ?, ?)")
+	assertLastArgs(t, int64(1), "a'b", int64(2), int64(3))
+
+	_, _ = db.Select(Test.F1).From(Test).
+		Where(Test.F1.In(db.Select(field3).From(table2).Where(field3.GreaterThan(10))), Test.F2.Contains("x")).
+		FetchFirst()
+	assertLastSql(t, "SELECT `f1` FROM `test` WHERE `f1` IN (SELECT `field3` FROM `table2` WHERE `field3` > ?) AND LOCATE(?, `f2`) > 0")
+	assertLastArgs(t, int64(10), "x")
+
+	_, _ = db.Select(Test.F1).From(Test).Where(Test.F2.Equals("y")).Limit(10).Count()
+	assertLastSql(t, "SELECT COUNT(1) FROM (SELECT 1 FROM `test` WHERE `f2` = ? LIMIT 10) AS t")
+	assertLastArgs(t, "y")
+
+	_, _ = db.InsertInto(Test).Values(1, []byte{0, 1}).OnDuplicateKeyUpdate().Set(Test.F2, tm).Execute()
+	assertLastSql(t, "INSERT INTO `test` (`f1`, `f2`) VALUES (?, ?) ON DUPLICATE KEY UPDATE `f2` = ?")
+	assertLastArgs(t, int64(1), []byte{0, 1}, tm)
+
+	_, _ = db.Update(Table1).Set(field1, true).Where(field2.IsNull(), field1.NotEquals(nil)).Execute()
+	assertLastSql(t, "UPDATE `table1` SET `field1` = ? WHERE `field2` IS NULL AND `field1` <> NULL")
+	assertLastArgs(t, true)
+
+	_, _ = db.DeleteFrom(Table1).Where(field1.Between(1.5, "z")).Execute()
+	assertLastSql(t, "DELETE FROM `table1` WHERE `field1` BETWEEN ? AND ?")
+	assertLastArgs(t, 1.5, "z")
+
+	sql, args, err := db.Select(field1).From(Table1).Where(field1.Equals("s")).GetSQLWithArgs()
+	if err != nil {
+		t.Error(err)
+	}
+	assertEqual(t, sql, "SELECT `field1` FROM `table1` WHERE `field1` = ?")
+	if len(args) != 1 || args[0] != "s" {
+		t.Error(args)
+	}
+
+	db.EnableParameterizedQuery(false)
+	sql, args, _ = db.Select(field1).From(Table1).Where(field1.Equals("s")).GetSQLWithArgs()
+	assertEqual(t, sql, "SELECT `field1` FROM `table1` WHERE `field1` = 's'")
+	if args != nil {
+		t.Error(args)
+	}
+}
+
+func TestParameterizedQueryPlaceholders(t *testing.T) {
+	sql, _ := newParameterizedMockDatabase(dialectPostgres).
+		Select(field1).From(Table1).Where(field1.Equals(1), field2.In(2, 3)).GetSQL()
+	assertEqual(t, sql, `SELECT "field1" FROM "table1" WHERE "field1" = $1 AND "field2" IN ($2, $3)`)
+
+	sql, _ = newParameterizedMockDatabase(dialectMSSQL).
+		Select(field1).From(Table1).Where(field1.Equals(1), field2.In(2, 3)).GetSQL()
+	assertEqual(t, sql, "SELECT [field1] FROM [table1] WHERE [field1] = @p1 AND [field2] IN (@p2, @p3)")
+
+	sql, _ = newParameterizedMockDatabase(dialectSqlite3).
+		Select(Test.F1).From(Test).Where(Test.F2.Contains("x")).GetSQL()
+	assertEqual(t, sql, `SELECT "f1" FROM "test" WHERE INSTR("f2", ?) > 0`)
+}
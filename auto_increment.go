@@ -0,0 +1,181 @@
+package sqlingo
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+)
+
+// autoIncrementTable is implemented by the generated table with an auto-increment primary key.
+type autoIncrementTable interface {
+	GetAutoIncrementField() Field
+}
+
+// autoIncrementIDGetter is implemented by the generated model with an auto-increment primary key.
+type autoIncrementIDGetter interface {
+	GetAutoIncrementID() int64
+}
+
+// autoIncrementModel is implemented by the pointer of the generated model with an auto-increment primary key.
+type autoIncrementModel interface {
+	autoIncrementIDGetter
+	SetAutoIncrementID(id int64)
+}
+
+func getModels(items []interface{}) ([]Model, error) {
+	models := make([]Model, 0, len(items))
+	for _, item := range items {
+		if err := addModel(&models, item); err != nil {
+			return nil, err
+		}
+	}
+	return models, nil
+}
+
+// isGeneratingAutoIncrementIDs tells whether all the models leave the auto-increment ids to the database.
+func isGeneratingAutoIncrementIDs(models []Model) (bool, error) {
+	zeroCount := 0
+	for _, model := range models {
+		getter, ok := model.(autoIncrementIDGetter)
+		if !ok {
+			return false, nil
+		}
+		if getter.GetAutoIncrementID() == 0 {
+			zeroCount++
+		}
+	}
+	if zeroCount != 0 && zeroCount != len(models) {
+		return false, errors.New("auto-increment ids of the models should be either all zero or all non-zero")
+	}
+	return zeroCount != 0, nil
+}
+
+// getAutoIncrementIndex returns the index of the auto-increment field of the table in fields, or -1 if not found.
+func getAutoIncrementIndex(scope scope, table Table, fields []Field) int {
+	autoIncrementTable, ok := table.(autoIncrementTable)
+	if !ok {
+		return -1
+	}
+	return getFieldIndex(scope, fields, autoIncrementTable.GetAutoIncrementField())
+}
+
+// getModelsToWriteBack returns the models whose auto-increment ids are to be generated by the database.
+// An error is returned before executing the statement if the ids to be generated cannot be determined after it.
+func (s insertStatus) getModelsToWriteBack() ([]autoIncrementModel, error) {
+	if len(s.models) == 0 || s.selectStatement != nil {
+		return nil, nil
+	}
+	if _, ok := s.scope.Tables[0].(autoIncrementTable); !ok {
+		return nil, nil
+	}
+	models, err := getModels(s.models)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]autoIncrementModel, len(models))
+	for i, model := range models {
+		autoIncrementModel, ok := model.(autoIncrementModel)
+		if !ok {
+			// models passed by value could not be written back
+			return nil, nil
+		}
+		result[i] = autoIncrementModel
+	}
+	generating, err := isGeneratingAutoIncrementIDs(models)
+	if err != nil && s.omitDefaults {
+		// the missing ids are rendered as DEFAULT, which are written back if the ids of all the rows are returned
+		if !s.isReturningAutoIncrementIDs() {
+			return nil, errors.New("cannot determine auto-increment ids of the models mixing zero and non-zero ids without RETURNING")
+		}
+		generating, err = true, nil
+		for i, model := range result {
+			if model.GetAutoIncrementID() != 0 {
+				result[i] = nil
+			}
+		}
+	}
+	if err != nil || !generating {
+		return nil, err
+	}
+	if err := s.checkAutoIncrementIDsDeterminable(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// isReturningAutoIncrementIDs tells whether the generated ids are returned by RETURNING.
+func (s insertStatus) isReturningAutoIncrementIDs() bool {
+	return s.scope.getDialect().AutoIncrementMode() == AutoIncrementReturning
+}
+
+// checkAutoIncrementIDsDeterminable returns an error if the ids generated by the statement cannot be determined after executing it.
+func (s insertStatus) checkAutoIncrementIDsDeterminable() error {
+	switch s.scope.getDialect().AutoIncrementMode() {
+	case AutoIncrementReturning:
+		if s.ignoreConflict {
+			return errors.New("cannot determine auto-increment ids of the ignored rows, which are not returned")
+		}
+		return nil
+	case AutoIncrementLastInsertID:
+		// the affected rows of an upsert or REPLACE do not match the inserted rows
+		if s.isUpsert() {
+			return errors.New("cannot determine auto-increment ids of upsert")
+		}
+		if s.method != "INSERT" {
+			return fmt.Errorf("cannot determine auto-increment ids of %s", s.method)
+		}
+		return nil
+	default:
+		return fmt.Errorf("cannot determine auto-increment ids in %s", s.scope.getDialect().Name())
+	}
+}
+
+// executeAndWriteBack executes the statement and writes the generated auto-increment ids back into the models.
+// The nil models are skipped. If the ids turn out to be undeterminable after the statement is executed,
+// the models are left untouched and the result is returned along with the error.
+func (s insertStatus) executeAndWriteBack(models []autoIncrementModel) (sql.Result, error) {
+	if s.isReturningAutoIncrementIDs() {
+		s.returning = []Field{s.scope.Tables[0].(autoIncrementTable).GetAutoIncrementField()}
+		var ids []int64
+		if _, err := (returningStatus{statement: s, database: s.scope.Database, ctx: s.ctx}).FetchAll(&ids); err != nil {
+			return nil, err
+		}
+		result := driver.RowsAffected(len(ids))
+		if len(ids) != len(models) {
+			return result, fmt.Errorf("cannot determine auto-increment ids: %d rows returned for %d models", len(ids), len(models))
+		}
+		for i, model := range models {
+			if model != nil {
+				model.SetAutoIncrementID(ids[i])
+			}
+		}
+		return result, nil
+	}
+
+	sqlString, args, err := s.GetSQLWithArgs()
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.scope.Database.ExecuteContext(s.ctx, sqlString, args...)
+	if err != nil {
+		return nil, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return result, fmt.Errorf("cannot determine auto-increment ids: %w", err)
+	}
+	if rowsAffected != int64(len(models)) {
+		return result, fmt.Errorf("cannot determine auto-increment ids: %d rows affected for %d models", rowsAffected, len(models))
+	}
+	// the id of the first inserted row, the ids of a multi-row INSERT are consecutive
+	// (assuming auto_increment_increment is 1)
+	firstID, err := result.LastInsertId()
+	if err != nil {
+		return result, fmt.Errorf("cannot determine auto-increment ids: %w", err)
+	}
+	for i, model := range models {
+		model.SetAutoIncrementID(firstID + int64(i))
+	}
+	return result, nil
+}
@@ -0,0 +1,332 @@
+package sqlingo
+
+import (
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+)
+
+type tAutoIncrementTest struct {
+	Table
+
+	Id        fAutoIncrementTestId
+	Name      fAutoIncrementTestName
+	CreatedAt fAutoIncrementTestCreatedAt
+}
+
+type fAutoIncrementTestId struct{ NumberField }
+type fAutoIncrementTestName struct{ StringField }
+type fAutoIncrementTestCreatedAt struct{ DateField }
+
+var oAutoIncrementTest = NewTable("auto_increment_test")
+
+var AutoIncrementTest = tAutoIncrementTest{
+	Table:     oAutoIncrementTest,
+	Id:        fAutoIncrementTestId{NewNumberField(oAutoIncrementTest, "id")},
+	Name:      fAutoIncrementTestName{NewStringField(oAutoIncrementTest, "name")},
+	CreatedAt: fAutoIncrementTestCreatedAt{NewDateField(oAutoIncrementTest, "created_at")},
+}
+
+func (t tAutoIncrementTest) GetFields() []Field {
+	return []Field{t.Id, t.Name, t.CreatedAt}
+}
+
+func (t tAutoIncrementTest) GetDefaultFields() []Field {
+	return []Field{t.Id, t.CreatedAt}
+}
+
+func (t tAutoIncrementTest) GetAutoIncrementField() Field {
+	return t.Id
+}
+
+type AutoIncrementTestModel struct {
+	Id        uint32
+	Name      string
+	CreatedAt time.Time
+}
+
+func (m AutoIncrementTestModel) GetTable() Table {
+	return AutoIncrementTest
+}
+
+func (m AutoIncrementTestModel) GetValues() []interface{} {
+	return []interface{}{m.Id, m.Name, m.CreatedAt}
+}
+
+func (m AutoIncrementTestModel) GetAutoIncrementID() int64 {
+	return int64(m.Id)
+}
+
+func (m *AutoIncrementTestModel) SetAutoIncrementID(id int64) {
+	m.Id = uint32(id)
+}
+
+type mockResult struct {
+	lastInsertId    int64
+	rowsAffected    int64
+	lastInsertIdErr error
+}
+
+func (m mockResult) LastInsertId() (int64, error) {
+	return m.lastInsertId, m.lastInsertIdErr
+}
+
+func (m mockResult) RowsAffected() (int64, error) {
+	return m.rowsAffected, nil
+}
+
+func TestAutoIncrementMySQL(t *testing.T) {
+	db := newMockDatabase()
+	sharedMockConn.execResult = mockResult{lastInsertId: 100, rowsAffected: 2}
+	defer func() {
+		sharedMockConn.execResult = nil
+	}()
+
+	m1 := &AutoIncrementTestModel{Name: "a"}
+	m2 := &AutoIncrementTestModel{Name: "b"}
+	if _, err := db.InsertInto(AutoIncrementTest).Models(m1, m2).Execute(); err != nil {
+		t.Error(err)
+	}
+	assertLastSql(t, "INSERT INTO `auto_increment_test` (`name`, `created_at`) VALUES ('a', NULL), ('b', NULL)")
+	if m1.Id != 100 || m2.Id != 101 {
+		t.Error(m1, m2)
+	}
+
+	// models with ids are inserted as is
+	if _, err := db.InsertInto(AutoIncrementTest).Models(m1, m2).Execute(); err != nil {
+		t.Error(err)
+	}
+	assertLastSql(t, "INSERT INTO `auto_increment_test` (`id`, `name`, `created_at`) VALUES (100, 'a', NULL), (101, 'b', NULL)")
+
+	// models passed by value could not be written back
+	models := []AutoIncrementTestModel{{Name: "a"}, {Name: "b"}}
+	if _, err := db.InsertInto(AutoIncrementTest).Models(models[0], models[1]).Execute(); err != nil {
+		t.Error(err)
+	}
+	assertLastSql(t, "INSERT INTO `auto_increment_test` (`name`, `created_at`) VALUES ('a', NULL), ('b', NULL)")
+	if models[0].Id != 0 {
+		t.Error(models)
+	}
+
+	// elements of slice are addressable
+	if _, err := db.InsertInto(AutoIncrementTest).Models(models).Execute(); err != nil {
+		t.Error(err)
+	}
+	if models[0].Id != 100 || models[1].Id != 101 {
+		t.Error(models)
+	}
+
+	if _, err := db.InsertInto(AutoIncrementTest).Models(&AutoIncrementTestModel{Id: 1}, &AutoIncrementTestModel{}).Execute(); err == nil {
+		t.Error("should get error here")
+	}
+
+	// the ids are left untouched if the rows affected do not match
+	m3 := &AutoIncrementTestModel{}
+	if result, err := db.InsertInto(AutoIncrementTest).Models(m3).Execute(); err == nil || result == nil || m3.Id != 0 {
+		t.Error(err, result, m3)
+	}
+
+	// the ids are left untouched if LastInsertId fails
+	sharedMockConn.execResult = mockResult{rowsAffected: 1, lastInsertIdErr: errors.New("no LastInsertId")}
+	if result, err := db.InsertInto(AutoIncrementTest).Models(m3).Execute(); err == nil || result == nil || m3.Id != 0 {
+		t.Error(err, result, m3)
+	}
+	sharedMockConn.execResult = mockResult{lastInsertId: 100, rowsAffected: 2}
+
+	// the ids of upsert cannot be determined, so the statement is not executed
+	m1, m2 = &AutoIncrementTestModel{}, &AutoIncrementTestModel{}
+	sharedMockConn.lastSql = ""
+	if _, err := db.InsertInto(AutoIncrementTest).Models(m1, m2).OnDuplicateKeyIgnore().Execute(); err == nil {
+		t.Error("should get error here")
+	}
+	if _, err := db.InsertInto(AutoIncrementTest).Models(m1, m2).OnDuplicateKeyUpdate().Set(AutoIncrementTest.Name, "x").Execute(); err == nil {
+		t.Error("should get error here")
+	}
+	if _, err := db.ReplaceInto(AutoIncrementTest).Models(m1, m2).Execute(); err == nil {
+		t.Error("should get error here")
+	}
+	if sharedMockConn.lastSql != "" || m1.Id != 0 || m2.Id != 0 {
+		t.Error(sharedMockConn.lastSql, m1, m2)
+	}
+
+	// the upsert of the models with ids is executed
+	m1.Id, m2.Id = 1, 2
+	if _, err := db.InsertInto(AutoIncrementTest).Models(m1, m2).OnDuplicateKeyIgnore().Execute(); err != nil {
+		t.Error(err)
+	}
+	assertLastSql(t, "INSERT IGNORE INTO `auto_increment_test` (`id`, `name`, `created_at`) VALUES (1, '', NULL), (2, '', NULL)")
+}
+
+func testAutoIncrementReturning(t *testing.T, dialect Dialect) {
+	db := newMockDatabase()
+	db.(*database).dialect = dialect
+	columnCount, rowCount := sharedMockConn.columnCount, sharedMockConn.rowCount
+	sharedMockConn.columnCount = 1
+	sharedMockConn.rowCount = 2
+	defer func() {
+		sharedMockConn.columnCount = columnCount
+		sharedMockConn.rowCount = rowCount
+	}()
+
+	m1 := &AutoIncrementTestModel{Name: "a"}
+	m2 := &AutoIncrementTestModel{Name: "b"}
+	result, err := db.InsertInto(AutoIncrementTest).Models(m1, m2).OnConflict(AutoIncrementTest.Name).DoUpdate().
+		Set(AutoIncrementTest.CreatedAt, Raw("NULL")).Execute()
+	if err != nil {
+		t.Error(err)
+	}
+	assertLastSql(t, `INSERT INTO "auto_increment_test" ("name", "created_at") VALUES ('a', NULL), ('b', NULL)`+
+		` ON CONFLICT ("name") DO UPDATE SET "created_at" = NULL RETURNING "id"`)
+	if m1.Id != 1 || m2.Id != 2 {
+		t.Error(m1, m2)
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected != 2 {
+		t.Error(rowsAffected)
+	}
+
+	// the ignored rows are not returned, so the statement is not executed
+	m1, m2 = &AutoIncrementTestModel{Name: "a"}, &AutoIncrementTestModel{Name: "b"}
+	sharedMockConn.lastSql = ""
+	if _, err := db.InsertInto(AutoIncrementTest).Models(m1, m2).OnConflict(AutoIncrementTest.Name).DoNothing().Execute(); err == nil {
+		t.Error("should get error here")
+	}
+	if sharedMockConn.lastSql != "" || m1.Id != 0 || m2.Id != 0 {
+		t.Error(sharedMockConn.lastSql, m1, m2)
+	}
+
+	// the models are left untouched if the rows returned do not match
+	m3 := &AutoIncrementTestModel{}
+	if result, err := db.InsertInto(AutoIncrementTest).Models(m3).Execute(); err == nil || result == nil || m3.Id != 0 {
+		t.Error(err, result, m3)
+	}
+}
+
+func TestAutoIncrementSQLite(t *testing.T) {
+	testAutoIncrementReturning(t, dialectSqlite3)
+}
+
+func TestAutoIncrementPostgres(t *testing.T) {
+	testAutoIncrementReturning(t, dialectPostgres)
+}
+
+func TestAutoIncrementMSSQL(t *testing.T) {
+	db := newMockDatabase()
+	db.(*database).dialect = dialectMSSQL
+	sharedMockConn.execResult = driver.RowsAffected(1)
+	defer func() {
+		sharedMockConn.execResult = nil
+	}()
+
+	// the generated ids cannot be determined, so the statement is not executed
+	m := &AutoIncrementTestModel{Name: "a"}
+	sharedMockConn.lastSql = ""
+	if _, err := db.InsertInto(AutoIncrementTest).Models(m).Execute(); err == nil || m.Id != 0 {
+		t.Error(err, m)
+	}
+	if sharedMockConn.lastSql != "" {
+		t.Error(sharedMockConn.lastSql)
+	}
+
+	m.Id = 1
+	if _, err := db.InsertInto(AutoIncrementTest).Models(m).Execute(); err != nil {
+		t.Error(err)
+	}
+	assertLastSql(t, "INSERT INTO [auto_increment_test] ([id], [name], [created_at]) VALUES (1, N'a', NULL)")
+}
+
+func TestOmitDefaults(t *testing.T) {
+	db := newMockDatabase()
+	createdAt := time.Date(2023, 9, 6, 18, 37, 46, 0, time.UTC)
+
+	m1 := AutoIncrementTestModel{Name: "a"}
+	m2 := AutoIncrementTestModel{Name: "b"}
+	m3 := AutoIncrementTestModel{Id: 3, Name: "c", CreatedAt: createdAt}
+
+	_, _ = db.InsertInto(AutoIncrementTest).Models(m1, m2).OmitDefaults().Execute()
+	assertLastSql(t, "INSERT INTO `auto_increment_test` (`name`) VALUES ('a'), ('b')")
+
+	_, _ = db.InsertInto(AutoIncrementTest).Models(m1, m3).OmitDefaults().Execute()
+	assertLastSql(t, "INSERT INTO `auto_increment_test` (`id`, `name`, `created_at`)"+
+		" VALUES (DEFAULT, 'a', DEFAULT), (3, 'c', '2023-09-06 18:37:46.000000')")
+
+	_, _ = db.InsertInto(AutoIncrementTest).Models(m3).Omit(AutoIncrementTest.Id, AutoIncrementTest.CreatedAt).Execute()
+	assertLastSql(t, "INSERT INTO `auto_increment_test` (`name`) VALUES ('c')")
+
+	if _, err := db.InsertInto(AutoIncrementTest).Models(m3).Omit(field1).Execute(); err == nil {
+		t.Error("should get error here")
+	}
+
+	sqlite := UseWithDialect(nil, dialectSqlite3)
+	if _, err := sqlite.InsertInto(AutoIncrementTest).Models(m1, m3).OmitDefaults().GetSQL(); err == nil {
+		t.Error("should get error here")
+	}
+}
+
+func TestOmitDefaultsWriteBack(t *testing.T) {
+	db := newMockDatabase()
+	sharedMockConn.execResult = mockResult{lastInsertId: 100, rowsAffected: 2}
+	defer func() {
+		sharedMockConn.execResult = nil
+	}()
+
+	// the ids of the DEFAULT rows cannot be determined by LastInsertId
+	m1 := &AutoIncrementTestModel{Name: "a"}
+	m2 := &AutoIncrementTestModel{Id: 3, Name: "b"}
+	sharedMockConn.lastSql = ""
+	if _, err := db.InsertInto(AutoIncrementTest).Models(m1, m2).OmitDefaults().Execute(); err == nil {
+		t.Error("should get error here")
+	}
+	if sharedMockConn.lastSql != "" || m1.Id != 0 || m2.Id != 3 {
+		t.Error(sharedMockConn.lastSql, m1, m2)
+	}
+
+	// all the ids are generated
+	m2.Id = 0
+	if _, err := db.InsertInto(AutoIncrementTest).Models(m1, m2).OmitDefaults().Execute(); err != nil {
+		t.Error(err)
+	}
+	assertLastSql(t, "INSERT INTO `auto_increment_test` (`name`) VALUES ('a'), ('b')")
+	if m1.Id != 100 || m2.Id != 101 {
+		t.Error(m1, m2)
+	}
+
+	// the ids of the DEFAULT rows are returned
+	db.(*database).dialect = dialectPostgres
+	columnCount, rowCount := sharedMockConn.columnCount, sharedMockConn.rowCount
+	sharedMockConn.columnCount = 1
+	sharedMockConn.rowCount = 2
+	defer func() {
+		sharedMockConn.columnCount = columnCount
+		sharedMockConn.rowCount = rowCount
+	}()
+	m1 = &AutoIncrementTestModel{Id: 5, Name: "a"}
+	m2 = &AutoIncrementTestModel{Name: "b"}
+	if _, err := db.InsertInto(AutoIncrementTest).Models(m1, m2).OmitDefaults().Execute(); err != nil {
+		t.Error(err)
+	}
+	assertLastSql(t, `INSERT INTO "auto_increment_test" ("id", "name") VALUES (5, 'a'), (DEFAULT, 'b') RETURNING "id"`)
+	if m1.Id != 5 || m2.Id != 2 {
+		t.Error(m1, m2)
+	}
+}
+
+func TestSetAllExceptModels(t *testing.T) {
+	postgres := UseWithDialect(nil, dialectPostgres)
+	m := &AutoIncrementTestModel{Name: "a"}
+	sql, _ := postgres.InsertInto(AutoIncrementTest).Models(m).OmitDefaults().
+		OnConflict(AutoIncrementTest.Name).DoUpdate().SetAllExcept().GetSQL()
+	assertEqual(t, sql, `INSERT INTO "auto_increment_test" ("name") VALUES ('a')`+
+		` ON CONFLICT ("name") DO UPDATE SET "name" = EXCLUDED."name"`)
+	sql, _ = postgres.InsertInto(AutoIncrementTest).Models(m).
+		OnConflict(AutoIncrementTest.Name).DoUpdate().Set(AutoIncrementTest.Name, "b").SetAllExcept(AutoIncrementTest.Name).GetSQL()
+	assertEqual(t, sql, `INSERT INTO "auto_increment_test" ("name", "created_at") VALUES ('a', NULL)`+
+		` ON CONFLICT ("name") DO UPDATE SET "name" = 'b', "created_at" = EXCLUDED."created_at"`)
+
+	db := newMockDatabase()
+	sql, _ = db.InsertInto(AutoIncrementTest).Models(m).OnDuplicateKeyUpdate().SetAllExcept().GetSQL()
+	assertEqual(t, sql, "INSERT INTO `auto_increment_test` (`name`, `created_at`) VALUES ('a', NULL)"+
+		" ON DUPLICATE KEY UPDATE `name` = VALUES(`name`), `created_at` = VALUES(`created_at`)")
+	sql, _ = db.InsertInto(TestWithPrimaryKey).Values(1, "a").OnDuplicateKeyUpdate().SetAllExcept().GetSQL()
+	assertEqual(t, sql, "INSERT INTO `test` (`f1`, `f2`) VALUES (1, 'a') ON DUPLICATE KEY UPDATE `f2` = VALUES(`f2`)")
+}
@@ -0,0 +1,173 @@
+package sqlingo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// BatchResult is the aggregated result of a batch insert.
+type BatchResult struct {
+	// Results are the results of the executed batches, nil for the failed ones.
+	Results []sql.Result
+	// Errors are the errors of the executed batches, nil for the succeeded ones.
+	Errors []error
+}
+
+// LastInsertId returns the last insert id of the last succeeded batch.
+func (r *BatchResult) LastInsertId() (int64, error) {
+	for i := len(r.Results) - 1; i >= 0; i-- {
+		if r.Results[i] != nil {
+			return r.Results[i].LastInsertId()
+		}
+	}
+	return 0, errors.New("no succeeded batch")
+}
+
+// RowsAffected returns the total number of rows affected by the succeeded batches.
+func (r *BatchResult) RowsAffected() (int64, error) {
+	var total int64
+	for _, result := range r.Results {
+		if result == nil {
+			continue
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		total += rowsAffected
+	}
+	return total, nil
+}
+
+// Err returns the error of the first failed batch, or nil if all batches succeed.
+func (r *BatchResult) Err() error {
+	for i, err := range r.Errors {
+		if err != nil {
+			return fmt.Errorf("batch %d of %d: %w", i+1, len(r.Errors), err)
+		}
+	}
+	return nil
+}
+
+type insertBatchStatus struct {
+	insertStatus  insertStatus
+	batchSize     int
+	inTransaction bool
+}
+
+type insertWithBatchSize interface {
+	toInsertBatchFinal
+	InTransaction() insertWithBatchSize
+	WithContext(ctx context.Context) toInsertBatchFinal
+}
+
+type toInsertBatchFinal interface {
+	Execute() (sql.Result, error)
+}
+
+type toInsertBatch interface {
+	BatchSize(batchSize int) insertWithBatchSize
+}
+
+// BatchSize splits the rows into multiple statements, each of which inserts at most batchSize rows.
+func (s insertStatus) BatchSize(batchSize int) insertWithBatchSize {
+	return insertBatchStatus{insertStatus: s, batchSize: batchSize}
+}
+
+// InTransaction executes all the batches in one transaction, which is rolled back if any batch fails.
+// The current transaction is reused if there is one.
+func (s insertBatchStatus) InTransaction() insertWithBatchSize {
+	s.inTransaction = true
+	return s
+}
+
+func (s insertBatchStatus) WithContext(ctx context.Context) toInsertBatchFinal {
+	s.insertStatus.ctx = ctx
+	return s
+}
+
+func (s insertBatchStatus) split() ([]insertStatus, error) {
+	if s.insertStatus.selectStatement != nil || s.batchSize <= 0 {
+		return []insertStatus{s.insertStatus}, nil
+	}
+
+	var rows []interface{}
+	isModel := len(s.insertStatus.models) > 0
+	if isModel {
+		models, err := getModels(s.insertStatus.models)
+		if err != nil {
+			return nil, err
+		}
+		rows = make([]interface{}, len(models))
+		for i, model := range models {
+			rows[i] = model
+		}
+	} else {
+		rows = s.insertStatus.values
+	}
+	if len(rows) <= s.batchSize {
+		return []insertStatus{s.insertStatus}, nil
+	}
+
+	statements := make([]insertStatus, 0, (len(rows)+s.batchSize-1)/s.batchSize)
+	for start := 0; start < len(rows); start += s.batchSize {
+		end := start + s.batchSize
+		if end > len(rows) {
+			end = len(rows)
+		}
+		statement := s.insertStatus
+		if isModel {
+			statement.models = rows[start:end:end]
+		} else {
+			statement.values = rows[start:end:end]
+		}
+		statements = append(statements, statement)
+	}
+	return statements, nil
+}
+
+// Execute executes the batches one by one. Without transaction, all batches are executed even if some fail,
+// and the error of the first failed batch is returned along with the aggregated result.
+func (s insertBatchStatus) Execute() (sql.Result, error) {
+	statements, err := s.split()
+	if err != nil {
+		return nil, err
+	}
+
+	var result *BatchResult
+	// the result is recreated on each attempt of the transaction, so the one of the last attempt is returned
+	execute := func(ctx context.Context) error {
+		result = &BatchResult{
+			Results: make([]sql.Result, 0, len(statements)),
+			Errors:  make([]error, 0, len(statements)),
+		}
+		for _, statement := range statements {
+			statement.ctx = ctx
+			batchResult, err := statement.Execute()
+			result.Results = append(result.Results, batchResult)
+			result.Errors = append(result.Errors, err)
+			if err != nil && s.inTransaction {
+				return result.Err()
+			}
+		}
+		return result.Err()
+	}
+
+	if !s.inTransaction {
+		return result, execute(s.insertStatus.ctx)
+	}
+	// the transaction is begun in the shard of all the rows
+	ctx, err := s.insertStatus.getShardContext(s.insertStatus.ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.insertStatus.scope.Database.EnsureTx(ctx, nil, execute); err != nil {
+		if result == nil {
+			return nil, err
+		}
+		return result, err
+	}
+	return result, nil
+}
@@ -0,0 +1,124 @@
+package sqlingo
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+)
+
+func TestBatchInsert(t *testing.T) {
+	db := newMockDatabase()
+	var sqls []string
+	failingSql := ""
+	deadlockOnce := false
+	db.SetInterceptor(func(ctx context.Context, sql string, invoker InvokerFunc) error {
+		sqls = append(sqls, sql)
+		if sql == failingSql && deadlockOnce {
+			failingSql = ""
+			return &mockMySQLError{Number: 1213}
+		}
+		if sql == failingSql {
+			return errors.New("error")
+		}
+		return invoker(ctx, sql)
+	})
+
+	models := []TestModel{{F1: 1, F2: "a"}, {F1: 2, F2: "b"}, {F1: 3, F2: "c"}}
+	if _, err := db.InsertInto(Test).Models(models).BatchSize(2).Execute(); err != nil {
+		t.Error(err)
+	}
+	if len(sqls) != 2 {
+		t.Error(sqls)
+	}
+	assertEqual(t, sqls[0], "INSERT INTO `test` (`f1`, `f2`) VALUES (1, 'a'), (2, 'b')")
+	assertEqual(t, sqls[1], "INSERT INTO `test` (`f1`, `f2`) VALUES (3, 'c')")
+
+	sqls = nil
+	if _, err := db.InsertInto(Table1).Fields(field1).Values(1).Values(2).Values(3).
+		OnDuplicateKeyIgnore().BatchSize(1).Execute(); err != nil {
+		t.Error(err)
+	}
+	if len(sqls) != 3 {
+		t.Error(sqls)
+	}
+	assertEqual(t, sqls[2], "INSERT IGNORE INTO `table1` (`field1`) VALUES (3)")
+
+	sqls = nil
+	if _, err := db.InsertInto(Test).Models(models).BatchSize(10).WithContext(context.Background()).Execute(); err != nil {
+		t.Error(err)
+	}
+	if len(sqls) != 1 {
+		t.Error(sqls)
+	}
+
+	// without transaction, the remaining batches are still executed
+	sqls = nil
+	failingSql = "INSERT INTO `test` (`f1`, `f2`) VALUES (1, 'a')"
+	result, err := db.InsertInto(Test).Models(models).BatchSize(1).Execute()
+	if err == nil {
+		t.Error("should get error here")
+	}
+	if len(sqls) != 3 {
+		t.Error(sqls)
+	}
+	if batchResult := result.(*BatchResult); batchResult.Errors[0] == nil || batchResult.Errors[1] != nil {
+		t.Error(batchResult.Errors)
+	}
+
+	// in transaction, it stops at the first failed batch and rolls back
+	sqls = nil
+	if _, err := db.InsertInto(Test).Models(models).BatchSize(1).InTransaction().Execute(); err == nil {
+		t.Error("should get error here")
+	}
+	if len(sqls) != 1 {
+		t.Error(sqls)
+	}
+	if !sharedMockConn.mockTx.isRolledBack {
+		t.Error("should be rolled back")
+	}
+
+	// the result of a retried transaction only has the batches of the committed attempt
+	db.SetTxRetryPolicy(&TxRetryPolicy{MaxAttempts: 2})
+	sqls = nil
+	failingSql = "INSERT INTO `test` (`f1`, `f2`) VALUES (2, 'b')"
+	deadlockOnce = true
+	result, err = db.InsertInto(Test).Models(models).BatchSize(1).InTransaction().Execute()
+	if err != nil {
+		t.Error(err)
+	}
+	if len(sqls) != 5 {
+		t.Error(sqls)
+	}
+	if batchResult := result.(*BatchResult); len(batchResult.Results) != 3 || batchResult.Err() != nil {
+		t.Error(batchResult)
+	}
+	db.SetTxRetryPolicy(nil)
+	deadlockOnce = false
+
+	if _, err := db.InsertInto(Test).Models(models, "invalid type").BatchSize(1).Execute(); err == nil {
+		t.Error("should get error here")
+	}
+}
+
+func TestBatchResult(t *testing.T) {
+	result := &BatchResult{
+		Results: []sql.Result{driver.RowsAffected(2), nil, driver.RowsAffected(3)},
+		Errors:  []error{nil, errors.New("error"), nil},
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil || rowsAffected != 5 {
+		t.Error(rowsAffected, err)
+	}
+	if _, err := result.LastInsertId(); err == nil {
+		t.Error("should get error here")
+	}
+	assertEqual(t, result.Err().Error(), "batch 2 of 3: error")
+
+	if err := (&BatchResult{}).Err(); err != nil {
+		t.Error(err)
+	}
+	if _, err := (&BatchResult{}).LastInsertId(); err == nil {
+		t.Error("should get error here")
+	}
+}
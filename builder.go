@@ -1,6 +1,24 @@
 package sqlingo
 
-import "strings"
+import (
+	"fmt"
+	"strings"
+)
+
+// isWhereEmpty tells whether the WHERE clause is absent or always true, which matches all the rows.
+func isWhereEmpty(where BooleanExpression) bool {
+	e, ok := where.(expression)
+	return where == nil || ok && e.isTrue
+}
+
+// checkSafeMode returns an error in safe mode if the UPDATE or DELETE statement matches all the rows without AllRows.
+func checkSafeMode(scope scope, method string, where BooleanExpression, allRows bool) error {
+	if allRows || !scope.Database.safeMode || !isWhereEmpty(where) {
+		return nil
+	}
+	return fmt.Errorf("%s of table %s without WHERE clause is refused in safe mode, use AllRows() to %s all the rows",
+		method, scope.Tables[0].GetName(), strings.ToLower(method))
+}
 
 func appendWhere(sb *strings.Builder, scope scope, where BooleanExpression) error {
 	if where == nil {
@@ -10,12 +28,13 @@ func appendWhere(sb *strings.Builder, scope scope, where BooleanExpression) erro
 		if e.isTrue {
 			return nil
 		} else if e.isFalse {
-			sb.WriteString(" WHERE FALSE")
+			sb.WriteString(" WHERE ")
+			sb.WriteString(scope.getDialect().BooleanConstant(false))
 			return nil
 		}
 	}
 
-	whereSql, err := where.GetSQL(scope)
+	whereSql, err := asCondition(where).GetSQL(scope)
 	if err != nil {
 		return err
 	}
@@ -23,3 +42,76 @@ func appendWhere(sb *strings.Builder, scope scope, where BooleanExpression) erro
 	sb.WriteString(whereSql)
 	return nil
 }
+
+// getJoins returns the joins in the order they are added.
+func getJoins(lastJoin *join) []*join {
+	var joins []*join
+	for j := lastJoin; j != nil; j = j.previous {
+		joins = append(joins, j)
+	}
+	for i, j := 0, len(joins)-1; i < j; i, j = i+1, j-1 {
+		joins[i], joins[j] = joins[j], joins[i]
+	}
+	return joins
+}
+
+func appendJoins(sb *strings.Builder, scope scope, joins []*join, tableHint string) error {
+	for _, join := range joins {
+		sb.WriteString(" ")
+		sb.WriteString(join.prefix)
+		sb.WriteString("JOIN ")
+		sb.WriteString(join.table.GetSQL(scope))
+		sb.WriteString(getTableHint(join.table, tableHint))
+		// cause on isn't a required part of join when using natural join,
+		// so move it to if statement
+		if join.on != nil {
+			onSql, err := join.on.GetSQL(scope)
+			if err != nil {
+				return err
+			}
+			sb.WriteString(" ON ")
+			sb.WriteString(onSql)
+		}
+	}
+	return nil
+}
+
+// andJoinCondition combines the ON condition of a join with the WHERE condition,
+// for the dialects which put the joined table in the FROM or USING clause of UPDATE and DELETE.
+func andJoinCondition(on BooleanExpression, where BooleanExpression) BooleanExpression {
+	if isWhereEmpty(where) {
+		return on
+	}
+	return on.And(where)
+}
+
+// appendOrderedTarget writes the common table expression which chooses the top rows of an UPDATE or DELETE statement
+// in order, for the dialects limiting the rows by TOP, and returns the name of it to update or delete from.
+func appendOrderedTarget(sb *strings.Builder, scope scope, top string, where BooleanExpression, orderBys []OrderBy) (string, error) {
+	target := scope.getDialect().QuoteIdentifier("sqlingo_target")
+	sb.WriteString("WITH ")
+	sb.WriteString(target)
+	sb.WriteString(" AS (SELECT")
+	sb.WriteString(top)
+	sb.WriteString(" * FROM ")
+	sb.WriteString(scope.Tables[0].GetSQL(scope))
+	if err := appendWhere(sb, scope, where); err != nil {
+		return "", err
+	}
+	orderBySql, err := commaOrderBys(scope, orderBys)
+	if err != nil {
+		return "", err
+	}
+	sb.WriteString(" ORDER BY ")
+	sb.WriteString(orderBySql)
+	sb.WriteString(") ")
+	return target, nil
+}
+
+// getLimit converts an optional limit to the form accepted by Dialect.LimitOffset and Dialect.UpdateLimit.
+func getLimit(limit *int) int {
+	if limit == nil {
+		return -1
+	}
+	return *limit
+}
@@ -19,3 +19,75 @@ func TestAppendWhere(t *testing.T) {
 	assertEqual(t, buildWhere(False()), " WHERE FALSE")
 	assertEqual(t, buildWhere(Raw("##")), " WHERE ##")
 }
+
+func TestStrictMode(t *testing.T) {
+	db, err := OpenStrict("sqlingo-mock", "dummy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.DeleteFrom(Table1).Where().GetSQL(); err == nil {
+		t.Error("should get error here")
+	}
+	if _, err := UseStrict("sqlingo-mock", db.GetDB()).Update(Table1).Set(field1, 1).Where().GetSQL(); err == nil {
+		t.Error("should get error here")
+	}
+	if _, err := OpenStrict("unknowndb", "unknown"); err == nil {
+		t.Error("should get error here")
+	}
+
+	db.EnableSafeMode(false)
+	if _, err := db.DeleteFrom(Table1).Where().GetSQL(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSafeMode(t *testing.T) {
+	db := newMockDatabase()
+	db.EnableSafeMode(true)
+	defer db.EnableSafeMode(false)
+
+	if _, err := db.DeleteFrom(Table1).Where().Execute(); err == nil {
+		t.Error("should get error here")
+	} else {
+		assertEqual(t, err.Error(), "DELETE of table table1 without WHERE clause is refused in safe mode, use AllRows() to delete all the rows")
+	}
+	if _, err := db.DeleteFrom(Table1).Where(True(), True()).Limit(1).Execute(); err == nil {
+		t.Error("should get error here")
+	}
+	if _, err := db.Update(Table1).Set(field1, 1).Where(True()).Execute(); err == nil {
+		t.Error("should get error here")
+	}
+	if _, err := db.Update(Table1).Join(table2).On(field1.Equals(field3)).Set(field1, 1).Where().Execute(); err == nil {
+		t.Error("should get error here")
+	}
+	postgres := UseWithDialect(nil, dialectPostgres)
+	postgres.EnableSafeMode(true)
+	if _, err := postgres.DeleteFrom(Table1).Where().Returning(field1).GetSQL(); err == nil {
+		t.Error("should get error here")
+	}
+
+	sharedMockConn.lastSql = ""
+	if _, err := db.DeleteFrom(Table1).AllRows().Execute(); err != nil {
+		t.Error(err)
+	}
+	assertLastSql(t, "DELETE FROM `table1`")
+	if _, err := db.DeleteFrom(Table1).Using(table2).AllRows().Execute(); err != nil {
+		t.Error(err)
+	}
+	assertLastSql(t, "DELETE `table1` FROM `table1`, `table2`")
+	if _, err := db.Update(Table1).Set(field1, 1).AllRows().Execute(); err != nil {
+		t.Error(err)
+	}
+	assertLastSql(t, "UPDATE `table1` SET `field1` = 1")
+
+	if _, err := db.DeleteFrom(Table1).Where(field1.Equals(1)).Execute(); err != nil {
+		t.Error(err)
+	}
+	if _, err := db.Update(TestWithPrimaryKey).SetModel(TestModel{F1: 1, F2: "a"}).Execute(); err != nil {
+		t.Error(err)
+	}
+	// no-op statement is not refused
+	if _, err := db.Update(Table1).SetIf(false, field1, 1).Where().Execute(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,469 @@
+package sqlingo
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// ReaderHandlerRegistrar registers a reader handler for LOAD DATA LOCAL INFILE 'Reader::name',
+// such as mysql.RegisterReaderHandler of github.com/go-sql-driver/mysql.
+type ReaderHandlerRegistrar func(name string, handler func() io.Reader)
+
+// ReaderHandlerDeregistrar removes a reader handler registered by ReaderHandlerRegistrar,
+// such as mysql.DeregisterReaderHandler of github.com/go-sql-driver/mysql.
+type ReaderHandlerDeregistrar func(name string)
+
+// maximum number of bound arguments of each INSERT statement in the fallback path,
+// below the limits of SQLite (32766) and PostgreSQL (65535)
+const bulkLoadMaxArgs = 30000
+
+const bulkLoadMaxRows = 1000
+
+var bulkLoadSequence int64
+
+// bulkLoadSource iterates the rows to be loaded.
+type bulkLoadSource interface {
+	// next returns the values of the next row, or io.EOF if there are no more rows.
+	next() ([]interface{}, error)
+}
+
+// rewindableSource is implemented by the source which can be iterated again from the first row,
+// so that the transaction loading it can be retried.
+type rewindableSource interface {
+	rewind()
+}
+
+type modelsSource struct {
+	models []Model
+	index  int
+}
+
+func (s *modelsSource) next() ([]interface{}, error) {
+	if s.index >= len(s.models) {
+		return nil, io.EOF
+	}
+	model := s.models[s.index]
+	s.index++
+	return model.GetValues(), nil
+}
+
+func (s *modelsSource) rewind() {
+	s.index = 0
+}
+
+type rowsSource struct {
+	rows  [][]interface{}
+	index int
+}
+
+func (s *rowsSource) next() ([]interface{}, error) {
+	if s.index >= len(s.rows) {
+		return nil, io.EOF
+	}
+	row := s.rows[s.index]
+	s.index++
+	return row, nil
+}
+
+func (s *rowsSource) rewind() {
+	s.index = 0
+}
+
+type channelSource struct {
+	channel reflect.Value
+}
+
+func (s channelSource) next() ([]interface{}, error) {
+	value, ok := s.channel.Recv()
+	if !ok {
+		return nil, io.EOF
+	}
+	if row, ok := value.Interface().([]interface{}); ok {
+		return row, nil
+	}
+	var models []Model
+	if err := addModel(&models, value.Interface()); err != nil {
+		return nil, err
+	}
+	if len(models) != 1 {
+		return nil, fmt.Errorf("%d models received as one row", len(models))
+	}
+	return models[0].GetValues(), nil
+}
+
+type csvSource struct {
+	reader *csv.Reader
+}
+
+func (s csvSource) next() ([]interface{}, error) {
+	record, err := s.reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	row := make([]interface{}, len(record))
+	for i, value := range record {
+		row[i] = value
+	}
+	return row, nil
+}
+
+func getBulkLoadSource(source interface{}) (bulkLoadSource, error) {
+	switch source := source.(type) {
+	case io.Reader:
+		return csvSource{reader: csv.NewReader(source)}, nil
+	case [][]interface{}:
+		return &rowsSource{rows: source}, nil
+	}
+
+	value := reflect.ValueOf(source)
+	if value.Kind() == reflect.Chan {
+		if value.Type().ChanDir()&reflect.RecvDir == 0 {
+			return nil, errors.New("channel of bulk load should be receivable")
+		}
+		return channelSource{channel: value}, nil
+	}
+	models, err := getModels([]interface{}{source})
+	if err != nil {
+		return nil, err
+	}
+	return &modelsSource{models: models}, nil
+}
+
+// BulkLoad loads the rows into the table with the fastest method of the dialect, and returns the number of rows loaded.
+// The source is one of:
+//   - the models, in any form accepted by InsertInto(table).Models;
+//   - [][]interface{} rows;
+//   - a channel of models or of []interface{} rows, which is consumed until closed;
+//   - an io.Reader of CSV without header.
+//
+// The values of each row are in the order of table.GetFields().
+//
+// In MySQL, the rows are streamed by LOAD DATA LOCAL INFILE if a reader handler is set by SetReaderHandler.
+// In PostgreSQL with github.com/lib/pq, the rows are streamed by COPY ... FROM STDIN.
+// Otherwise, the rows are inserted by multi-row INSERT statements in chunks. This includes PostgreSQL with other drivers
+// such as github.com/jackc/pgx, which supports COPY only by its own API pgx.Conn.CopyFrom.
+// The CSV is parsed by encoding/csv, so the lines can end with either "\n" or "\r\n",
+// and each value is loaded as a string, including the empty ones.
+// Except for LOAD DATA, the rows are loaded in one transaction, and the current transaction is reused if there is one.
+// The transaction is retried by the TxRetryPolicy, except for a channel or an io.Reader which cannot be read again.
+func (d *database) BulkLoad(table Table, source interface{}) (int64, error) {
+	return d.BulkLoadContext(context.Background(), table, source)
+}
+
+// BulkLoadContext is the same as BulkLoad with context.
+func (d *database) BulkLoadContext(ctx context.Context, table Table, source interface{}) (int64, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	fields := table.GetFields()
+	if len(fields) == 0 {
+		return 0, errors.New("no fields in table")
+	}
+	scope := scope{Database: d, Tables: []Table{table}}
+	if d.shards != nil || d.shardOf != nil {
+		if err := scope.checkShardKey(ctx); err != nil {
+			return 0, err
+		}
+		shard, err := d.getShard(ctx)
+		if err != nil {
+			return 0, err
+		}
+		if shard != d {
+			return shard.BulkLoadContext(ctx, table, source)
+		}
+	}
+
+	rows, err := getBulkLoadSource(source)
+	if err != nil {
+		return 0, err
+	}
+	switch d.dialect.BulkLoadMethod() {
+	case BulkLoadLoadData:
+		if d.registerReaderHandler != nil {
+			return d.loadData(ctx, scope, fields, rows)
+		}
+	case BulkLoadCopy:
+		if d.db != nil && isLibPQ(d.db.Driver()) {
+			return d.copyFrom(ctx, scope, fields, rows)
+		}
+	}
+	return d.insertInChunks(ctx, scope, fields, rows)
+}
+
+// SetReaderHandler sets the functions to register and deregister reader handlers,
+// which enables LOAD DATA LOCAL INFILE in BulkLoad of MySQL.
+// With github.com/go-sql-driver/mysql, use
+//
+//	db.SetReaderHandler(mysql.RegisterReaderHandler, mysql.DeregisterReaderHandler)
+//
+// The server should have local_infile enabled.
+func (d *database) SetReaderHandler(register ReaderHandlerRegistrar, deregister ReaderHandlerDeregistrar) {
+	d.registerReaderHandler = register
+	d.deregisterReaderHandler = deregister
+}
+
+func isLibPQ(d driver.Driver) bool {
+	driverType := reflect.TypeOf(d)
+	if driverType == nil {
+		return false
+	}
+	if driverType.Kind() == reflect.Ptr {
+		driverType = driverType.Elem()
+	}
+	return driverType.PkgPath() == "github.com/lib/pq"
+}
+
+// intercept runs f with the interceptor and the logger, bypassing the statement cache,
+// for the statements which cannot be prepared.
+func (d *database) intercept(ctx context.Context, sqlString string, f func(ctx context.Context) error) error {
+	sqlStringWithCallerInfo := getCallerInfo(d, false) + sqlString
+	startTime := time.Now()
+	defer func() {
+		endTime := time.Now()
+		if d.logger != nil {
+			d.logger(sqlStringWithCallerInfo, endTime.Sub(startTime), d.tx != nil, false)
+		}
+	}()
+
+	invoker := func(ctx context.Context, sqlString string) error {
+		return f(ctx)
+	}
+	if d.interceptor == nil {
+		return invoker(ctx, sqlStringWithCallerInfo)
+	}
+	return d.interceptor(ctx, sqlStringWithCallerInfo, invoker)
+}
+
+// loadData streams the rows by LOAD DATA LOCAL INFILE.
+// The CSV of an io.Reader is parsed and written again as well, so that its line endings and NULL values are the same as other sources.
+func (d *database) loadData(ctx context.Context, scope scope, fields []Field, rows bulkLoadSource) (int64, error) {
+	fieldsSql, err := commaFields(scope, fields)
+	if err != nil {
+		return 0, err
+	}
+	tableSql := scope.getDialect().QuoteIdentifier(scope.Tables[0].GetName())
+
+	reader, writer := io.Pipe()
+	// unblocks the writer if the driver returns without reading all the rows
+	defer reader.Close()
+	go func() {
+		_ = writer.CloseWithError(writeLoadDataRows(writer, rows, len(fields)))
+	}()
+
+	name := "sqlingo_" + strconv.FormatInt(atomic.AddInt64(&bulkLoadSequence, 1), 10)
+	d.registerReaderHandler(name, func() io.Reader { return reader })
+	if d.deregisterReaderHandler != nil {
+		defer d.deregisterReaderHandler(name)
+	}
+
+	sqlString := "LOAD DATA LOCAL INFILE " + quoteString("Reader::"+name) +
+		" INTO TABLE " + tableSql + " CHARACTER SET utf8mb4" +
+		" FIELDS TERMINATED BY ',' OPTIONALLY ENCLOSED BY '\"' ESCAPED BY ''" +
+		" LINES TERMINATED BY '\\n' (" + fieldsSql + ")"
+
+	var result sql.Result
+	err = d.intercept(ctx, sqlString, func(ctx context.Context) (err error) {
+		result, err = d.getTxOrDB(ctx).ExecContext(ctx, sqlString)
+		return
+	})
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// writeLoadDataRows writes the rows as CSV for the LOAD DATA statement without escape character,
+// in which each value is enclosed by double quotes, and NULL is the unquoted word NULL.
+func writeLoadDataRows(w io.Writer, rows bulkLoadSource, fieldCount int) error {
+	var buf bytes.Buffer
+	for {
+		row, err := rows.next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if len(row) != fieldCount {
+			return fmt.Errorf("%d values for %d fields", len(row), fieldCount)
+		}
+
+		buf.Reset()
+		for i, value := range row {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			s, isNull, err := formatLoadDataValue(value)
+			if err != nil {
+				return err
+			}
+			if isNull {
+				buf.WriteString("NULL")
+				continue
+			}
+			buf.WriteByte('"')
+			buf.WriteString(strings.ReplaceAll(s, `"`, `""`))
+			buf.WriteByte('"')
+		}
+		buf.WriteByte('\n')
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			return err
+		}
+	}
+}
+
+func formatLoadDataValue(value interface{}) (s string, isNull bool, err error) {
+	value, err = driver.DefaultParameterConverter.ConvertValue(value)
+	if err != nil {
+		return
+	}
+	switch value := value.(type) {
+	case nil:
+		isNull = true
+	case int64:
+		s = strconv.FormatInt(value, 10)
+	case float64:
+		s = strconv.FormatFloat(value, 'g', -1, 64)
+	case bool:
+		s = dialectMySQL.FormatBool(value)
+	case []byte:
+		s = string(value)
+	case string:
+		s = value
+	case time.Time:
+		s = value.Format("2006-01-02 15:04:05.000000")
+	default:
+		err = fmt.Errorf("unknown value type %T", value)
+	}
+	return
+}
+
+// ensureBulkLoadTx runs f in a transaction, which is retried by the TxRetryPolicy only if the rows can be iterated again,
+// but not for a channel or an io.Reader consumed by the failed attempt.
+func (d *database) ensureBulkLoadTx(ctx context.Context, rows bulkLoadSource, f func(ctx context.Context) error) error {
+	rewindable, ok := rows.(rewindableSource)
+	if !ok {
+		ctx = WithTxRetryPolicy(ctx, nil)
+	}
+	return d.EnsureTx(ctx, nil, func(ctx context.Context) error {
+		if ok {
+			rewindable.rewind()
+		}
+		return f(ctx)
+	})
+}
+
+// copyFrom streams the rows by COPY ... FROM STDIN with the protocol of github.com/lib/pq,
+// in which each row is sent by executing the prepared COPY statement, and the data is flushed by executing it without arguments.
+func (d *database) copyFrom(ctx context.Context, scope scope, fields []Field, rows bulkLoadSource) (count int64, err error) {
+	fieldsSql, err := commaFields(scope, fields)
+	if err != nil {
+		return 0, err
+	}
+	sqlString := "COPY " + scope.getDialect().QuoteIdentifier(scope.Tables[0].GetName()) +
+		" (" + fieldsSql + ") FROM STDIN"
+
+	err = d.ensureBulkLoadTx(ctx, rows, func(ctx context.Context) error {
+		count = 0
+		tx := ctx.Value(txContextKey{}).(Transaction)
+		executor := d
+		if txDatabase, ok := tx.(*database); ok {
+			executor = txDatabase
+		}
+		return executor.intercept(ctx, sqlString, func(ctx context.Context) error {
+			stmt, err := tx.GetTx().PrepareContext(ctx, sqlString)
+			if err != nil {
+				return err
+			}
+			defer stmt.Close()
+
+			for {
+				row, err := rows.next()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					return err
+				}
+				if len(row) != len(fields) {
+					return fmt.Errorf("%d values for %d fields", len(row), len(fields))
+				}
+				if _, err := stmt.ExecContext(ctx, row...); err != nil {
+					return err
+				}
+				count++
+			}
+			_, err = stmt.ExecContext(ctx)
+			return err
+		})
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func getBulkLoadChunkSize(fieldCount int, parameterized bool) int {
+	if !parameterized || bulkLoadMaxRows*fieldCount <= bulkLoadMaxArgs {
+		return bulkLoadMaxRows
+	}
+	if fieldCount > bulkLoadMaxArgs {
+		return 1
+	}
+	return bulkLoadMaxArgs / fieldCount
+}
+
+func (d *database) insertInChunks(ctx context.Context, scope scope, fields []Field, rows bulkLoadSource) (count int64, err error) {
+	chunkSize := getBulkLoadChunkSize(len(fields), d.parameterized)
+
+	err = d.ensureBulkLoadTx(ctx, rows, func(ctx context.Context) error {
+		count = 0
+		statement := insertStatus{method: "INSERT", scope: scope, fields: fields, ctx: ctx}
+		flush := func() error {
+			if len(statement.values) == 0 {
+				return nil
+			}
+			if _, err := statement.Execute(); err != nil {
+				return err
+			}
+			count += int64(len(statement.values))
+			statement.values = nil
+			return nil
+		}
+
+		for {
+			row, err := rows.next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			if len(row) != len(fields) {
+				return fmt.Errorf("%d values for %d fields", len(row), len(fields))
+			}
+			statement.values = append(statement.values, row)
+			if len(statement.values) >= chunkSize {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+		return flush()
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
@@ -0,0 +1,249 @@
+package sqlingo
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBulkLoadInsert(t *testing.T) {
+	db := newMockDatabase()
+	var sqls []string
+	db.SetInterceptor(func(ctx context.Context, sql string, invoker InvokerFunc) error {
+		sqls = append(sqls, sql)
+		return invoker(ctx, sql)
+	})
+
+	models := []TestModel{{F1: 1, F2: "a"}, {F1: 2, F2: "b"}}
+	if n, err := db.BulkLoad(Test, models); n != 2 || err != nil {
+		t.Error(n, err)
+	}
+	if len(sqls) != 1 {
+		t.Error(sqls)
+	}
+	assertLastSql(t, "INSERT INTO `test` (`f1`, `f2`) VALUES (1, 'a'), (2, 'b')")
+
+	ch := make(chan interface{}, 3)
+	ch <- TestModel{F1: 1, F2: "a"}
+	ch <- &TestModel{F1: 2, F2: "b"}
+	ch <- []interface{}{3, "c"}
+	close(ch)
+	if n, err := db.BulkLoad(Test, (<-chan interface{})(ch)); n != 3 || err != nil {
+		t.Error(n, err)
+	}
+	assertLastSql(t, "INSERT INTO `test` (`f1`, `f2`) VALUES (1, 'a'), (2, 'b'), (3, 'c')")
+
+	if n, err := db.BulkLoad(Test, strings.NewReader("1,a\n2,\"b,c\"\n")); n != 2 || err != nil {
+		t.Error(n, err)
+	}
+	assertLastSql(t, "INSERT INTO `test` (`f1`, `f2`) VALUES ('1', 'a'), ('2', 'b,c')")
+
+	rows := make([][]interface{}, bulkLoadMaxRows*2+1)
+	for i := range rows {
+		rows[i] = []interface{}{i, "x"}
+	}
+	sqls = nil
+	if n, err := db.BulkLoad(Test, rows); n != int64(len(rows)) || err != nil {
+		t.Error(n, err)
+	}
+	if len(sqls) != 3 {
+		t.Error(len(sqls))
+	}
+	assertLastSql(t, "INSERT INTO `test` (`f1`, `f2`) VALUES (2000, 'x')")
+
+	// chunked by the number of arguments in parameterized mode
+	if size := getBulkLoadChunkSize(100, false); size != bulkLoadMaxRows {
+		t.Error(size)
+	}
+	if size := getBulkLoadChunkSize(100, true); size != bulkLoadMaxArgs/100 {
+		t.Error(size)
+	}
+	if size := getBulkLoadChunkSize(bulkLoadMaxArgs+1, true); size != 1 {
+		t.Error(size)
+	}
+
+	if _, err := db.BulkLoad(Test, [][]interface{}{{1}}); err == nil {
+		t.Error("should get error here")
+	}
+	if _, err := db.BulkLoad(Test, "invalid type"); err == nil {
+		t.Error("should get error here")
+	}
+	if _, err := db.BulkLoad(Test, make(chan<- interface{})); err == nil {
+		t.Error("should get error here")
+	}
+	if _, err := db.BulkLoad(Test, strings.NewReader("1,a\n2\n")); err == nil {
+		t.Error("should get error here")
+	}
+	if !sharedMockConn.mockTx.isRolledBack {
+		t.Error("should be rolled back")
+	}
+}
+
+func TestBulkLoadRetry(t *testing.T) {
+	db := newMockDatabase()
+	db.SetTxRetryPolicy(&TxRetryPolicy{MaxAttempts: 3})
+	attempts := 0
+	db.SetInterceptor(func(ctx context.Context, sql string, invoker InvokerFunc) error {
+		attempts++
+		if attempts == 1 {
+			return &mockMySQLError{Number: 1213}
+		}
+		return invoker(ctx, sql)
+	})
+
+	// the rows are loaded again from the first one, and counted once
+	models := []TestModel{{F1: 1, F2: "a"}, {F1: 2, F2: "b"}}
+	if n, err := db.BulkLoad(Test, models); n != 2 || err != nil || attempts != 2 {
+		t.Error(n, err, attempts)
+	}
+	assertLastSql(t, "INSERT INTO `test` (`f1`, `f2`) VALUES (1, 'a'), (2, 'b')")
+
+	// the consumed channel is not retried
+	attempts = 0
+	ch := make(chan interface{}, 2)
+	ch <- []interface{}{1, "a"}
+	ch <- []interface{}{2, "b"}
+	close(ch)
+	if n, err := db.BulkLoad(Test, ch); n != 0 || err == nil || attempts != 1 {
+		t.Error(n, err, attempts)
+	}
+
+	// nor the consumed reader
+	attempts = 0
+	if n, err := db.BulkLoad(Test, strings.NewReader("1,a\n2,b\n")); n != 0 || err == nil || attempts != 1 {
+		t.Error(n, err, attempts)
+	}
+}
+
+func TestBulkLoadData(t *testing.T) {
+	db := newMockDatabase()
+	handlers := map[string]func() io.Reader{}
+	db.SetReaderHandler(func(name string, handler func() io.Reader) {
+		handlers[name] = handler
+	}, func(name string) {
+		delete(handlers, name)
+	})
+
+	var loaded []byte
+	db.SetInterceptor(func(ctx context.Context, sql string, invoker InvokerFunc) error {
+		if len(handlers) != 1 {
+			t.Error(handlers)
+		}
+		for name, handler := range handlers {
+			if !strings.HasPrefix(sql, "LOAD DATA LOCAL INFILE 'Reader::"+name+"' INTO TABLE `test` CHARACTER SET utf8mb4 "+
+				"FIELDS TERMINATED BY ',' OPTIONALLY ENCLOSED BY '\"' ESCAPED BY '' LINES TERMINATED BY '\\n' (`f1`, `f2`)") {
+				t.Error(sql)
+			}
+			var err error
+			if loaded, err = ioutil.ReadAll(handler()); err != nil {
+				return err
+			}
+		}
+		return invoker(ctx, sql)
+	})
+
+	sharedMockConn.execResult = driver.RowsAffected(2)
+	defer func() {
+		sharedMockConn.execResult = nil
+	}()
+
+	var nilString *string
+	rows := [][]interface{}{{1, `a"b`}, {2.5, nilString}}
+	if n, err := db.BulkLoad(Test, rows); n != 2 || err != nil {
+		t.Error(n, err)
+	}
+	assertEqual(t, string(loaded), "\"1\",\"a\"\"b\"\n\"2.5\",NULL\n")
+	if len(handlers) != 0 {
+		t.Error(handlers)
+	}
+
+	if _, err := db.BulkLoad(Test, strings.NewReader("1,a\r\n2,\"b\r\nc\"\r\n")); err != nil {
+		t.Error(err)
+	}
+	assertEqual(t, string(loaded), "\"1\",\"a\"\n\"2\",\"b\nc\"\n")
+
+	if _, err := db.BulkLoad(Test, [][]interface{}{{struct{}{}, "a"}}); err == nil {
+		t.Error("should get error here")
+	}
+	if _, err := db.BulkLoad(Test, "invalid type"); err == nil {
+		t.Error("should get error here")
+	}
+}
+
+func TestWriteLoadDataRows(t *testing.T) {
+	tm := time.Date(2023, 9, 6, 18, 37, 46, 828000000, time.UTC)
+	var buf bytes.Buffer
+	rows := &rowsSource{rows: [][]interface{}{{true, []byte("x"), tm, nil}}}
+	if err := writeLoadDataRows(&buf, rows, 4); err != nil {
+		t.Error(err)
+	}
+	assertEqual(t, buf.String(), "\"1\",\"x\",\"2023-09-06 18:37:46.828000\",NULL\n")
+
+	if err := writeLoadDataRows(&buf, &rowsSource{rows: [][]interface{}{{1}}}, 2); err == nil {
+		t.Error("should get error here")
+	}
+	if err := writeLoadDataRows(errorWriter{}, &rowsSource{rows: [][]interface{}{{1}}}, 1); err == nil {
+		t.Error("should get error here")
+	}
+}
+
+type errorWriter struct{}
+
+func (errorWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("error")
+}
+
+func TestBulkLoadCopy(t *testing.T) {
+	db := newMockDatabase().(*database)
+	db.dialect = dialectPostgres
+
+	var sqls []string
+	db.SetInterceptor(func(ctx context.Context, sql string, invoker InvokerFunc) error {
+		sqls = append(sqls, sql)
+		return invoker(ctx, sql)
+	})
+	loggedInTx := false
+	db.SetLogger(func(sql string, duration time.Duration, isTx bool, retry bool) {
+		if strings.HasPrefix(sql, "COPY ") {
+			loggedInTx = isTx
+		}
+	})
+
+	scope := scope{Database: db, Tables: []Table{Test}}
+	rows := &rowsSource{rows: [][]interface{}{{1, "a"}, {2, "b"}}}
+	if n, err := db.copyFrom(context.Background(), scope, Test.GetFields(), rows); n != 2 || err != nil {
+		t.Error(n, err)
+	}
+	if len(sqls) != 1 {
+		t.Error(sqls)
+	}
+	assertLastSql(t, `COPY "test" ("f1", "f2") FROM STDIN`)
+	if !loggedInTx {
+		t.Error("should be logged in transaction")
+	}
+	// flushed by executing without arguments
+	if len(sharedMockConn.lastArgs) != 0 {
+		t.Error(sharedMockConn.lastArgs)
+	}
+	if !sharedMockConn.mockTx.isCommitted {
+		t.Error("should be committed")
+	}
+
+	rows = &rowsSource{rows: [][]interface{}{{1}}}
+	if _, err := db.copyFrom(context.Background(), scope, Test.GetFields(), rows); err == nil {
+		t.Error("should get error here")
+	}
+	if !sharedMockConn.mockTx.isRolledBack {
+		t.Error("should be rolled back")
+	}
+
+	if isLibPQ(db.db.Driver()) {
+		t.Error("should not be lib/pq")
+	}
+}
@@ -0,0 +1,228 @@
+package sqlingo
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ClusterDatabase is the interface of a database cluster with a primary and replicas.
+// The SELECT statements without locking are sent to a healthy replica chosen by the balancer,
+// and the other statements, the transactions and the queries with a context returned by WithPrimary are sent to the primary.
+type ClusterDatabase interface {
+	Database
+
+	// GetReplicas returns the replicas of the cluster.
+	GetReplicas() []*Replica
+	// SetBalancer sets the balancer choosing the replica for each query, which is round-robin by default.
+	SetBalancer(balancer Balancer)
+	// CheckHealth pings all the replicas, ejects the failing ones and restores the recovered ones.
+	CheckHealth(ctx context.Context)
+	// StartHealthCheck runs CheckHealth at the interval in the background, until ctx is done.
+	StartHealthCheck(ctx context.Context, interval time.Duration)
+}
+
+// Replica is a replica database of a cluster.
+type Replica struct {
+	db        *sql.DB
+	stmtCache *stmtCache
+	unhealthy int32
+	// latency is the moving average of the query and ping latencies in nanoseconds
+	latency int64
+}
+
+// GetDB returns the underlying sql.DB object of the replica.
+func (r *Replica) GetDB() *sql.DB {
+	return r.db
+}
+
+// IsHealthy tells whether the replica passed the last health check.
+func (r *Replica) IsHealthy() bool {
+	return atomic.LoadInt32(&r.unhealthy) == 0
+}
+
+// GetLatency returns the moving average latency of the replica, or zero if not measured yet.
+func (r *Replica) GetLatency() time.Duration {
+	return time.Duration(atomic.LoadInt64(&r.latency))
+}
+
+func (r *Replica) setHealthy(healthy bool) {
+	if healthy {
+		atomic.StoreInt32(&r.unhealthy, 0)
+	} else {
+		atomic.StoreInt32(&r.unhealthy, 1)
+	}
+}
+
+func (r *Replica) recordLatency(latency time.Duration) {
+	for {
+		old := atomic.LoadInt64(&r.latency)
+		average := int64(latency)
+		if old != 0 {
+			average = old + (int64(latency)-old)/5
+		}
+		if atomic.CompareAndSwapInt64(&r.latency, old, average) {
+			return
+		}
+	}
+}
+
+// Balancer chooses the replica for each query.
+type Balancer interface {
+	// Pick returns one of the healthy replicas, which are never empty.
+	Pick(replicas []*Replica) *Replica
+}
+
+type roundRobinBalancer struct {
+	counter uint64
+}
+
+// NewRoundRobinBalancer returns a balancer choosing the replicas in turn.
+func NewRoundRobinBalancer() Balancer {
+	return &roundRobinBalancer{}
+}
+
+func (b *roundRobinBalancer) Pick(replicas []*Replica) *Replica {
+	return replicas[(atomic.AddUint64(&b.counter, 1)-1)%uint64(len(replicas))]
+}
+
+type leastLatencyBalancer struct{}
+
+// NewLeastLatencyBalancer returns a balancer choosing the replica with the least moving average latency.
+// The replicas not measured yet are chosen first.
+func NewLeastLatencyBalancer() Balancer {
+	return leastLatencyBalancer{}
+}
+
+func (leastLatencyBalancer) Pick(replicas []*Replica) *Replica {
+	best := replicas[0]
+	for _, replica := range replicas[1:] {
+		if replica.GetLatency() < best.GetLatency() {
+			best = replica
+		}
+	}
+	return best
+}
+
+// replicaSet is shared by the database of a cluster and its transactions.
+type replicaSet struct {
+	mutex    sync.RWMutex
+	replicas []*Replica
+	balancer Balancer
+}
+
+// pick returns the replica chosen by the balancer, or nil if no replica is healthy.
+func (s *replicaSet) pick() *Replica {
+	healthyReplicas := make([]*Replica, 0, len(s.replicas))
+	for _, replica := range s.replicas {
+		if replica.IsHealthy() {
+			healthyReplicas = append(healthyReplicas, replica)
+		}
+	}
+	if len(healthyReplicas) == 0 {
+		return nil
+	}
+	s.mutex.RLock()
+	balancer := s.balancer
+	s.mutex.RUnlock()
+	return balancer.Pick(healthyReplicas)
+}
+
+type cluster struct {
+	*database
+}
+
+// Cluster uses existing *sql.DB handles of the primary and the replicas as a cluster,
+// the dialect is chosen by the driver name as Use.
+func Cluster(driverName string, primary *sql.DB, replicas ...*sql.DB) ClusterDatabase {
+	d := Use(driverName, primary).(*database)
+	d.replicas = &replicaSet{balancer: NewRoundRobinBalancer()}
+	for _, db := range replicas {
+		d.replicas.replicas = append(d.replicas.replicas, &Replica{db: db, stmtCache: newStmtCache()})
+	}
+	return cluster{database: d}
+}
+
+func (c cluster) GetReplicas() []*Replica {
+	return c.replicas.replicas
+}
+
+func (c cluster) SetBalancer(balancer Balancer) {
+	c.replicas.mutex.Lock()
+	defer c.replicas.mutex.Unlock()
+	c.replicas.balancer = balancer
+}
+
+func (c cluster) CheckHealth(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, replica := range c.replicas.replicas {
+		wg.Add(1)
+		go func(replica *Replica) {
+			defer wg.Done()
+			startTime := time.Now()
+			err := replica.db.PingContext(ctx)
+			if err == nil {
+				replica.recordLatency(time.Since(startTime))
+			}
+			replica.setHealthy(err == nil)
+		}(replica)
+	}
+	wg.Wait()
+}
+
+func (c cluster) StartHealthCheck(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				checkCtx, cancel := context.WithTimeout(ctx, interval)
+				c.CheckHealth(checkCtx)
+				cancel()
+			}
+		}
+	}()
+}
+
+type primaryContextKey struct{}
+
+// WithPrimary returns a context in which the queries are sent to the primary of a cluster,
+// to read the writes just made.
+func WithPrimary(ctx context.Context) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, primaryContextKey{}, true)
+}
+
+type readOnlyContextKey struct{}
+
+// withReadOnly marks the query in the context as read-only, which could be sent to a replica.
+func withReadOnly(ctx context.Context) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, readOnlyContextKey{}, true)
+}
+
+// getReplica returns the replica for the query, or nil if it should be sent to the primary.
+func (d *database) getReplica(ctx context.Context) *Replica {
+	if d.replicas == nil || d.tx != nil || ctx == nil {
+		return nil
+	}
+	if readOnly, _ := ctx.Value(readOnlyContextKey{}).(bool); !readOnly {
+		return nil
+	}
+	if primary, _ := ctx.Value(primaryContextKey{}).(bool); primary {
+		return nil
+	}
+	if _, ok := ctx.Value(txContextKey{}).(Transaction); ok {
+		return nil
+	}
+	return d.replicas.pick()
+}
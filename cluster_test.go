@@ -0,0 +1,155 @@
+package sqlingo
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+)
+
+type mockReplicaConn struct {
+	*mockConn
+	pingError error
+}
+
+func (m *mockReplicaConn) Ping(ctx context.Context) error {
+	return m.pingError
+}
+
+type mockReplicaDriver struct{}
+
+var mockReplicaConns = map[string]*mockReplicaConn{}
+
+func (mockReplicaDriver) Open(name string) (driver.Conn, error) {
+	return mockReplicaConns[name], nil
+}
+
+func init() {
+	sql.Register("sqlingo-mock-replica", mockReplicaDriver{})
+}
+
+func newMockReplica(name string) (*sql.DB, *mockReplicaConn) {
+	conn := &mockReplicaConn{mockConn: &mockConn{columnCount: 11, rowCount: 10}}
+	mockReplicaConns[name] = conn
+	db, err := sql.Open("sqlingo-mock-replica", name)
+	if err != nil {
+		panic(err)
+	}
+	return db, conn
+}
+
+func newMockCluster() (ClusterDatabase, *mockReplicaConn, *mockReplicaConn) {
+	primary, err := sql.Open("sqlingo-mock", "dummy")
+	if err != nil {
+		panic(err)
+	}
+	replica1, conn1 := newMockReplica("replica1")
+	replica2, conn2 := newMockReplica("replica2")
+	db := Cluster("mysql", primary, replica1, replica2)
+	return db, conn1, conn2
+}
+
+func TestCluster(t *testing.T) {
+	db, conn1, conn2 := newMockCluster()
+	if len(db.GetReplicas()) != 2 || db.GetReplicas()[0].GetDB() == db.GetDB() {
+		t.Error(db.GetReplicas())
+	}
+	sharedMockConn.lastSql = ""
+
+	// round-robin
+	_, _ = db.SelectFrom(Table1).FetchAll()
+	assertEqual(t, conn1.lastSql, "SELECT <fields sql> FROM `table1`")
+	_, _ = db.SelectFrom(Table1).Where(field1.Equals(1)).FetchAll()
+	assertEqual(t, conn2.lastSql, "SELECT <fields sql> FROM `table1` WHERE `field1` = 1")
+	assertLastSql(t, "")
+	if db.GetReplicas()[0].GetLatency() == 0 {
+		t.Error("latency should be measured")
+	}
+
+	// locking reads, writes, raw queries and transactions go to the primary
+	_, _ = db.SelectFrom(Table1).ForUpdate().FetchAll()
+	assertLastSql(t, "SELECT <fields sql> FROM `table1` FOR UPDATE")
+	_, _ = db.DeleteFrom(Table1).Where(field1.Equals(1)).Execute()
+	assertLastSql(t, "DELETE FROM `table1` WHERE `field1` = 1")
+	_, _ = db.Query("SELECT 1")
+	assertLastSql(t, "SELECT 1")
+	_ = db.BeginTx(context.Background(), nil, func(tx Transaction) error {
+		_, _ = tx.SelectFrom(Table1).Where(field1.Equals(2)).FetchAll()
+		return nil
+	})
+	assertLastSql(t, "SELECT <fields sql> FROM `table1` WHERE `field1` = 2")
+	_ = db.EnsureTx(context.Background(), nil, func(ctx context.Context) error {
+		_, _ = db.SelectFrom(Table1).Where(field1.Equals(3)).WithContext(ctx).FetchAll()
+		return nil
+	})
+	assertLastSql(t, "SELECT <fields sql> FROM `table1` WHERE `field1` = 3")
+	_, _ = db.SelectFrom(Table1).Where(field1.Equals(4)).WithContext(WithPrimary(context.Background())).FetchAll()
+	assertLastSql(t, "SELECT <fields sql> FROM `table1` WHERE `field1` = 4")
+	_, _ = db.SelectFrom(Table1).Limit(1).WithContext(WithPrimary(context.Background())).Count()
+	assertLastSql(t, "SELECT COUNT(1) FROM (SELECT 1 FROM `table1` LIMIT 1) AS t")
+	_, _ = db.SelectFrom(Table1).WithContext(WithPrimary(context.Background())).Exists()
+	assertLastSql(t, "SELECT EXISTS (SELECT <fields sql> FROM `table1`)")
+	_, _ = db.SelectFrom(Table1).ForUpdate().Exists()
+	assertLastSql(t, "SELECT EXISTS (SELECT <fields sql> FROM `table1` FOR UPDATE)")
+	_, _ = db.SelectFrom(Table1).Limit(2).ForUpdate().Count()
+	assertLastSql(t, "SELECT COUNT(1) FROM (SELECT 1 FROM `table1` LIMIT 2 FOR UPDATE) AS t")
+	if conn1.lastSql != "SELECT <fields sql> FROM `table1`" {
+		t.Error(conn1.lastSql)
+	}
+
+	// least-latency
+	db.SetBalancer(NewLeastLatencyBalancer())
+	db.GetReplicas()[0].latency = int64(time.Second)
+	db.GetReplicas()[1].latency = int64(time.Millisecond)
+	_, _ = db.SelectFrom(Table1).Where(field1.Equals(5)).FetchAll()
+	assertEqual(t, conn2.lastSql, "SELECT <fields sql> FROM `table1` WHERE `field1` = 5")
+
+	// statement caches of the replicas
+	db.EnableParameterizedQuery(true)
+	db.SetStatementCacheSize(10)
+	_, _ = db.SelectFrom(Table1).Where(field1.Equals(6)).FetchAll()
+	_, _ = db.SelectFrom(Table1).Where(field1.Equals(7)).FetchAll()
+	if stats := db.GetStatementCacheStats(); stats.Hits != 1 || stats.Misses != 1 || stats.Size != 1 {
+		t.Error(stats)
+	}
+	db.SetStatementCacheSize(0)
+	db.EnableParameterizedQuery(false)
+}
+
+func TestClusterHealthCheck(t *testing.T) {
+	db, conn1, conn2 := newMockCluster()
+	replicas := db.GetReplicas()
+
+	conn1.pingError = errors.New("error")
+	db.CheckHealth(context.Background())
+	if replicas[0].IsHealthy() || !replicas[1].IsHealthy() {
+		t.Error(replicas[0].IsHealthy(), replicas[1].IsHealthy())
+	}
+	for i := 0; i < 2; i++ {
+		conn2.lastSql = ""
+		_, _ = db.SelectFrom(Table1).Where(field1.Equals(i)).FetchAll()
+		if conn2.lastSql == "" {
+			t.Error("should be sent to the healthy replica")
+		}
+	}
+
+	// falls back to the primary if no replica is healthy
+	conn2.pingError = errors.New("error")
+	db.CheckHealth(context.Background())
+	_, _ = db.SelectFrom(Table1).Where(field1.Equals(3)).FetchAll()
+	assertLastSql(t, "SELECT <fields sql> FROM `table1` WHERE `field1` = 3")
+
+	// restored by the background health check
+	conn1.pingError = nil
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	db.StartHealthCheck(ctx, time.Millisecond)
+	for i := 0; i < 1000 && !replicas[0].IsHealthy(); i++ {
+		time.Sleep(time.Millisecond)
+	}
+	if !replicas[0].IsHealthy() || replicas[1].IsHealthy() {
+		t.Error(replicas[0].IsHealthy(), replicas[1].IsHealthy())
+	}
+}
@@ -50,6 +50,32 @@ func command(name string, arg interface{}) expression {
 	}}
 }
 
+func getFieldsSQL(scope scope, fields []Field) ([]string, error) {
+	fieldsSql := make([]string, len(fields))
+	for i, field := range fields {
+		fieldSql, err := field.GetSQL(scope)
+		if err != nil {
+			return nil, err
+		}
+		fieldsSql[i] = fieldSql
+	}
+	return fieldsSql, nil
+}
+
+// getFieldIndex returns the index of field in fields, or -1 if not found.
+func getFieldIndex(scope scope, fields []Field, field Field) int {
+	fieldSql, err := field.GetSQL(scope)
+	if err != nil {
+		return -1
+	}
+	for i, item := range fields {
+		if itemSql, err := item.GetSQL(scope); err == nil && itemSql == fieldSql {
+			return i
+		}
+	}
+	return -1
+}
+
 func commaFields(scope scope, fields []Field) (string, error) {
 	var sqlBuilder strings.Builder
 	sqlBuilder.Grow(128)
@@ -82,7 +108,7 @@ func commaExpressions(scope scope, expressions []Expression) (string, error) {
 	return sqlBuilder.String(), nil
 }
 
-func commaTables(scope scope, tables []Table) string {
+func commaTables(scope scope, tables []Table, tableHint string) string {
 	var sqlBuilder strings.Builder
 	sqlBuilder.Grow(32)
 	for i, table := range tables {
@@ -90,10 +116,19 @@ func commaTables(scope scope, tables []Table) string {
 			sqlBuilder.WriteString(", ")
 		}
 		sqlBuilder.WriteString(table.GetSQL(scope))
+		sqlBuilder.WriteString(getTableHint(table, tableHint))
 	}
 	return sqlBuilder.String()
 }
 
+// getTableHint returns the hint following the table, which only applies to the tables but not the derived tables.
+func getTableHint(table Table, tableHint string) string {
+	if _, ok := table.(derivedTable); ok {
+		return ""
+	}
+	return tableHint
+}
+
 func commaValues(scope scope, values []interface{}) (string, error) {
 	var sqlBuilder strings.Builder
 	for i, item := range values {
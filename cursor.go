@@ -31,6 +31,14 @@ func (c cursor) Next() bool {
 	return c.rows.Next()
 }
 
+func (c cursor) columns() ([]string, error) {
+	return c.rows.Columns()
+}
+
+func (c cursor) columnTypes() ([]*sql.ColumnType, error) {
+	return c.rows.ColumnTypes()
+}
+
 var timeType = reflect.TypeOf(time.Time{})
 
 var simpleTimeLayoutRegexp = regexp.MustCompile(`^\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}(\.(\d+))?$`)
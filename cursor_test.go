@@ -13,14 +13,17 @@ type mockDriver struct{}
 
 type mockConn struct {
 	lastSql      string
+	lastArgs     []driver.Value
 	mockTx       *mockTx
 	beginTxError error
 	prepareError error
+	execResult   driver.Result
 	columnCount  int
 	rowCount     int
 }
 
 type mockStmt struct {
+	conn        *mockConn
 	columnCount int
 	rowCount    int
 }
@@ -35,6 +38,10 @@ func (m mockRows) Columns() []string {
 	return []string{"a", "b", "c", "d", "e", "f", "g", "h", "j", "k", "l"}[:m.columnCount]
 }
 
+func (m mockRows) ColumnTypeDatabaseTypeName(index int) string {
+	return []string{"VARCHAR", "FLOAT", "INT", "CHAR", "VARCHAR", "VARCHAR", "INT", "DATETIME", "VARCHAR", "DATETIME", "DATETIME"}[index]
+}
+
 func (m mockRows) Close() error {
 	return nil
 }
@@ -78,14 +85,19 @@ func (m mockStmt) Close() error {
 }
 
 func (m mockStmt) NumInput() int {
-	return 0
+	return -1
 }
 
 func (m mockStmt) Exec(args []driver.Value) (driver.Result, error) {
+	m.conn.lastArgs = args
+	if m.conn.execResult != nil {
+		return m.conn.execResult, nil
+	}
 	return driver.ResultNoRows, nil
 }
 
 func (m mockStmt) Query(args []driver.Value) (driver.Rows, error) {
+	m.conn.lastArgs = args
 	return &mockRows{
 		columnCount: m.columnCount,
 		rowCount:    m.rowCount,
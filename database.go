@@ -20,35 +20,35 @@ const (
 	reset = "\033[0m"
 )
 
-// Database is the interface of a database with underlying sql.DB object.
-type Database interface {
+// Executor is the common interface of Database and Transaction.
+// Functions accepting an Executor work both inside and outside a transaction.
+type Executor interface {
 	// GetDB returns the underlying sql.DB object of the database
 	GetDB() *sql.DB
-	// BeginTx starts a transaction and executes the function f.
-	BeginTx(ctx context.Context, opts *sql.TxOptions, f func(tx Transaction) error) error
 	// EnsureTx ensures the function f runs within a transaction.
 	// If ctx already contains a transaction started by a previous EnsureTx call, it reuses that transaction.
 	// Otherwise, it begins a new transaction and stores it in the context.
 	EnsureTx(ctx context.Context, opts *sql.TxOptions, f func(ctx context.Context) error) error
+	// EnsureNestedTx is similar to EnsureTx, but if ctx already contains a transaction,
+	// it runs the function f in a savepoint of that transaction.
+	EnsureNestedTx(ctx context.Context, opts *sql.TxOptions, f func(ctx context.Context) error) error
+	// EnsureTxWithPropagation ensures the function f runs within a transaction,
+	// decided by the propagation if ctx already contains a transaction.
+	EnsureTxWithPropagation(ctx context.Context, opts *sql.TxOptions, propagation TxPropagation, f func(ctx context.Context) error) error
 	// Query executes a query and returns the cursor
-	Query(sql string) (Cursor, error)
+	Query(sql string, args ...interface{}) (Cursor, error)
 	// QueryContext executes a query with context and returns the cursor
-	QueryContext(ctx context.Context, sqlString string) (Cursor, error)
+	QueryContext(ctx context.Context, sqlString string, args ...interface{}) (Cursor, error)
 	// Execute executes a statement
-	Execute(sql string) (sql.Result, error)
+	Execute(sql string, args ...interface{}) (sql.Result, error)
 	// ExecuteContext executes a statement with context
-	ExecuteContext(ctx context.Context, sql string) (sql.Result, error)
-	// SetLogger sets the logger function.
-	// Deprecated: use SetInterceptor instead
-	SetLogger(logger LoggerFunc)
-	// SetRetryPolicy sets the retry policy function.
-	// Deprecated: use SetInterceptor instead
-	SetRetryPolicy(retryPolicy func(err error) bool)
-	// EnableCallerInfo enable or disable the caller info in the log.
-	// Deprecated: use SetInterceptor instead
-	EnableCallerInfo(enableCallerInfo bool)
-	// SetInterceptor sets an interceptor function
-	SetInterceptor(interceptor InterceptorFunc)
+	ExecuteContext(ctx context.Context, sql string, args ...interface{}) (sql.Result, error)
+	// BulkLoad loads the rows from models, a channel or an io.Reader of CSV into the table,
+	// and returns the number of rows loaded.
+	// COPY FROM STDIN is used only with github.com/lib/pq, other PostgreSQL drivers fall back to chunked inserts.
+	BulkLoad(table Table, source interface{}) (int64, error)
+	// BulkLoadContext loads the rows into the table with context.
+	BulkLoadContext(ctx context.Context, table Table, source interface{}) (int64, error)
 
 	// Select initiates a SELECT statement
 	Select(fields ...interface{}) selectWithFields
@@ -61,11 +61,53 @@ type Database interface {
 	// ReplaceInto initiates a REPLACE INTO statement
 	ReplaceInto(table Table) insertWithTable
 	// Update initiates a UPDATE statement
-	Update(table Table) updateWithSet
+	Update(table Table) updateWithTable
 	// DeleteFrom initiates a DELETE FROM statement
 	DeleteFrom(table Table) deleteWithTable
 }
 
+// Database is the interface of a database with underlying sql.DB object.
+type Database interface {
+	Executor
+
+	// BeginTx starts a transaction and executes the function f.
+	// The whole transaction is retried according to the retry policy set by SetTxRetryPolicy or WithTxRetryPolicy.
+	BeginTx(ctx context.Context, opts *sql.TxOptions, f func(tx Transaction) error) error
+	// SetTxRetryPolicy sets the default retry policy of the transactions begun by BeginTx and EnsureTx, nil disables retries.
+	SetTxRetryPolicy(policy *TxRetryPolicy)
+	// SetLogger sets the logger function.
+	// Deprecated: use SetInterceptor instead
+	SetLogger(logger LoggerFunc)
+	// SetRetryPolicy sets the retry policy function.
+	// Deprecated: use SetInterceptor instead
+	SetRetryPolicy(retryPolicy func(err error) bool)
+	// EnableCallerInfo enable or disable the caller info in the log.
+	// Deprecated: use SetInterceptor instead
+	EnableCallerInfo(enableCallerInfo bool)
+	// SetInterceptor sets an interceptor function
+	SetInterceptor(interceptor InterceptorFunc)
+	// GetInterceptor returns the interceptor function
+	GetInterceptor() InterceptorFunc
+	// EnableParameterizedQuery enables or disables the parameterized query mode.
+	// When enabled, values are sent to the driver as bound arguments instead of being inlined as literals.
+	EnableParameterizedQuery(enableParameterizedQuery bool)
+	// SetStatementCacheSize sets the capacity of the prepared statement cache, zero disables it.
+	// The cache is mostly useful in parameterized query mode, in which the same SQL string is executed repeatedly.
+	// The cached statements are shared by the call sites, so they are prepared without the caller info.
+	SetStatementCacheSize(size int)
+	// GetStatementCacheStats returns the hit and miss counters of the prepared statement cache,
+	// summed up with the caches of the replicas in a cluster.
+	GetStatementCacheStats() StatementCacheStats
+	// EnableSafeMode enables or disables the safe mode, which is disabled by default,
+	// except for the database in strict mode opened by OpenStrict or UseStrict.
+	// In safe mode, UPDATE and DELETE statements without WHERE clause, or with WHERE clause always true,
+	// are refused unless AllRows is called.
+	EnableSafeMode(enableSafeMode bool)
+	// SetReaderHandler sets the functions to register and deregister reader handlers of the MySQL driver,
+	// which enables LOAD DATA LOCAL INFILE in BulkLoad.
+	SetReaderHandler(register ReaderHandlerRegistrar, deregister ReaderHandlerDeregistrar)
+}
+
 type txOrDB interface {
 	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
 	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
@@ -80,10 +122,22 @@ type database struct {
 	db               *sql.DB
 	tx               *sql.Tx
 	logger           LoggerFunc
-	dialect          dialect
+	dialect          Dialect
 	retryPolicy      func(error) bool
 	enableCallerInfo bool
 	interceptor      InterceptorFunc
+	parameterized    bool
+	stmtCache        *stmtCache
+	safeMode         bool
+	txRetryPolicy    *TxRetryPolicy
+	txCallbacks      *txCallbacks
+	replicas         *replicaSet
+	shards           *shardSet
+	shardOf          *shardSet // the shard set which the database of a shard or its transaction belongs to
+	shardIndex       int
+
+	registerReaderHandler   ReaderHandlerRegistrar
+	deregisterReaderHandler ReaderHandlerDeregistrar
 }
 
 type LoggerFunc func(sql string, duration time.Duration, isTx bool, retry bool)
@@ -164,6 +218,53 @@ func (d *database) SetInterceptor(interceptor InterceptorFunc) {
 	d.interceptor = interceptor
 }
 
+func (d *database) GetInterceptor() InterceptorFunc {
+	return d.interceptor
+}
+
+func (d *database) EnableParameterizedQuery(enableParameterizedQuery bool) {
+	d.parameterized = enableParameterizedQuery
+}
+
+func (d *database) EnableSafeMode(enableSafeMode bool) {
+	d.safeMode = enableSafeMode
+}
+
+func (d *database) SetStatementCacheSize(size int) {
+	d.stmtCache.resize(size)
+	if d.replicas != nil {
+		// each replica has its own cache, as the statements are prepared on its own connections
+		for _, replica := range d.replicas.replicas {
+			replica.stmtCache.resize(size)
+		}
+	}
+	if d.shards != nil {
+		for _, cache := range d.shards.stmtCaches {
+			cache.resize(size)
+		}
+	}
+}
+
+func (d *database) GetStatementCacheStats() StatementCacheStats {
+	var stats StatementCacheStats
+	caches := []*stmtCache{d.stmtCache}
+	if d.replicas != nil {
+		for _, replica := range d.replicas.replicas {
+			caches = append(caches, replica.stmtCache)
+		}
+	}
+	if d.shards != nil {
+		caches = append(caches, d.shards.stmtCaches...)
+	}
+	for _, cache := range caches {
+		cacheStats := cache.stats()
+		stats.Hits += cacheStats.Hits
+		stats.Misses += cacheStats.Misses
+		stats.Size += cacheStats.Size
+	}
+	return stats
+}
+
 // Open a database, similar to sql.Open.
 // `db` using a default logger, which print log to stderr and regard executing time gt 100ms as slow sql.
 // To disable the default logger, use `db.SetLogger(nil)`.
@@ -179,11 +280,40 @@ func Open(driverName string, dataSourceName string) (db Database, err error) {
 	return
 }
 
-// Use an existing *sql.DB handle
+// OpenStrict opens a database in strict mode, similar to Open but with safe mode enabled.
+func OpenStrict(driverName string, dataSourceName string) (db Database, err error) {
+	db, err = Open(driverName, dataSourceName)
+	if err != nil {
+		return
+	}
+	db.EnableSafeMode(true)
+	return
+}
+
+// Use an existing *sql.DB handle.
+// The dialect is chosen by the driver name, see RegisterDriverDialect,
+// or by the type of the driver if the name is unknown.
 func Use(driverName string, sqlDB *sql.DB) Database {
+	dialect := getDialectFromDriverName(driverName)
+	if dialect == dialectUnknown && sqlDB != nil {
+		dialect = getDialectFromDriver(sqlDB.Driver())
+	}
+	return UseWithDialect(sqlDB, dialect)
+}
+
+// UseStrict uses an existing *sql.DB handle in strict mode, similar to Use but with safe mode enabled.
+func UseStrict(driverName string, sqlDB *sql.DB) Database {
+	db := Use(driverName, sqlDB)
+	db.EnableSafeMode(true)
+	return db
+}
+
+// UseWithDialect uses an existing *sql.DB handle with the specified dialect.
+func UseWithDialect(sqlDB *sql.DB, dialect Dialect) Database {
 	return &database{
-		dialect: getDialectFromDriverName(driverName),
-		db:      sqlDB,
+		dialect:   dialect,
+		db:        sqlDB,
+		stmtCache: newStmtCache(),
 	}
 }
 
@@ -203,15 +333,23 @@ func (d *database) getTxOrDB(ctx context.Context) txOrDB {
 	return d.db
 }
 
-func (d *database) Query(sqlString string) (Cursor, error) {
-	return d.QueryContext(context.Background(), sqlString)
+func (d *database) Query(sqlString string, args ...interface{}) (Cursor, error) {
+	return d.QueryContext(context.Background(), sqlString, args...)
 }
 
-func (d *database) QueryContext(ctx context.Context, sqlString string) (Cursor, error) {
+func (d *database) QueryContext(ctx context.Context, sqlString string, args ...interface{}) (Cursor, error) {
+	if d.shards != nil || d.shardOf != nil {
+		shard, err := d.getShard(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if shard != d {
+			return shard.QueryContext(ctx, sqlString, args...)
+		}
+	}
 	isRetry := false
 	for {
-		sqlStringWithCallerInfo := getCallerInfo(d, isRetry) + sqlString
-		rows, err := d.queryContextOnce(ctx, sqlStringWithCallerInfo, isRetry)
+		rows, err := d.queryContextOnce(ctx, getCallerInfo(d, isRetry), sqlString, args, isRetry)
 		if err != nil {
 			isRetry = d.tx == nil && d.retryPolicy != nil && d.retryPolicy(err)
 			if isRetry {
@@ -223,30 +361,49 @@ func (d *database) QueryContext(ctx context.Context, sqlString string) (Cursor,
 	}
 }
 
-func (d *database) queryContextOnce(ctx context.Context, sqlString string, retry bool) (*sql.Rows, error) {
+func (d *database) queryContextOnce(ctx context.Context, callerInfo string, sqlString string, args []interface{}, retry bool) (*sql.Rows, error) {
 	if ctx == nil {
 		ctx = context.Background()
 	}
+	sqlStringWithCallerInfo := callerInfo + sqlString
 	startTime := time.Now()
 	defer func() {
 		endTime := time.Now()
 		if d.logger != nil {
-			d.logger(sqlString, endTime.Sub(startTime), false, retry)
+			d.logger(sqlStringWithCallerInfo, endTime.Sub(startTime), false, retry)
 		}
 	}()
 
 	interceptor := d.interceptor
 	var rows *sql.Rows
-	invoker := func(ctx context.Context, sql string) (err error) {
-		rows, err = d.getTxOrDB(ctx).QueryContext(ctx, sql)
-		return
+	invoker := func(ctx context.Context, sqlString string) (err error) {
+		db, txOrDB, stmtCache := d.db, d.getTxOrDB(ctx), d.stmtCache
+		if replica := d.getReplica(ctx); replica != nil {
+			db, txOrDB, stmtCache = replica.db, replica.db, replica.stmtCache
+			startTime := time.Now()
+			defer func() {
+				if err == nil {
+					replica.recordLatency(time.Since(startTime))
+				}
+			}()
+		}
+		if !stmtCache.isEnabled() {
+			rows, err = txOrDB.QueryContext(ctx, sqlString, args...)
+			return
+		}
+		// the statements are shared by the call sites
+		sqlString = strings.TrimPrefix(sqlString, callerInfo)
+		return stmtCache.withStmt(ctx, db, txOrDB, sqlString, func(stmt *sql.Stmt) (err error) {
+			rows, err = stmt.QueryContext(ctx, args...)
+			return
+		})
 	}
 
 	var err error
 	if interceptor == nil {
-		err = invoker(ctx, sqlString)
+		err = invoker(ctx, sqlStringWithCallerInfo)
 	} else {
-		err = interceptor(ctx, sqlString, invoker)
+		err = interceptor(ctx, sqlStringWithCallerInfo, invoker)
 	}
 	if err != nil {
 		return nil, err
@@ -255,16 +412,26 @@ func (d *database) queryContextOnce(ctx context.Context, sqlString string, retry
 	return rows, nil
 }
 
-func (d *database) Execute(sqlString string) (sql.Result, error) {
-	return d.ExecuteContext(context.Background(), sqlString)
+func (d *database) Execute(sqlString string, args ...interface{}) (sql.Result, error) {
+	return d.ExecuteContext(context.Background(), sqlString, args...)
 }
 
 // ExecuteContext todo Is there need retry?
-func (d *database) ExecuteContext(ctx context.Context, sqlString string) (sql.Result, error) {
+func (d *database) ExecuteContext(ctx context.Context, sqlString string, args ...interface{}) (sql.Result, error) {
+	if d.shards != nil || d.shardOf != nil {
+		shard, err := d.getShard(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if shard != d {
+			return shard.ExecuteContext(ctx, sqlString, args...)
+		}
+	}
 	if ctx == nil {
 		ctx = context.Background()
 	}
-	sqlStringWithCallerInfo := getCallerInfo(d, false) + sqlString
+	callerInfo := getCallerInfo(d, false)
+	sqlStringWithCallerInfo := callerInfo + sqlString
 	startTime := time.Now()
 	defer func() {
 		endTime := time.Now()
@@ -274,9 +441,16 @@ func (d *database) ExecuteContext(ctx context.Context, sqlString string) (sql.Re
 	}()
 
 	var result sql.Result
-	invoker := func(ctx context.Context, sql string) (err error) {
-		result, err = d.getTxOrDB(ctx).ExecContext(ctx, sql)
-		return
+	invoker := func(ctx context.Context, sqlString string) (err error) {
+		if !d.stmtCache.isEnabled() {
+			result, err = d.getTxOrDB(ctx).ExecContext(ctx, sqlString, args...)
+			return
+		}
+		sqlString = strings.TrimPrefix(sqlString, callerInfo)
+		return d.stmtCache.withStmt(ctx, d.db, d.getTxOrDB(ctx), sqlString, func(stmt *sql.Stmt) (err error) {
+			result, err = stmt.ExecContext(ctx, args...)
+			return
+		})
 	}
 	var err error
 	if d.interceptor == nil {
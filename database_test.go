@@ -15,6 +15,7 @@ func (m *mockConn) Prepare(query string) (driver.Stmt, error) {
 	}
 	m.lastSql = query
 	return &mockStmt{
+		conn:        m,
 		columnCount: m.columnCount,
 		rowCount:    m.rowCount,
 	}, nil
@@ -3,25 +3,47 @@ package sqlingo
 import (
 	"context"
 	"database/sql"
-	"strconv"
+	"errors"
+	"fmt"
 	"strings"
 )
 
 type deleteStatus struct {
-	scope    scope
-	where    BooleanExpression
-	orderBys []OrderBy
-	limit    *int
-	ctx      context.Context
+	scope     scope
+	where     BooleanExpression
+	orderBys  []OrderBy
+	limit     *int
+	returning []Field
+	allRows   bool
+	ctx       context.Context
 }
 
 type deleteWithTable interface {
+	toDeleteJoin
+	Using(tables ...Table) deleteWithJoinOn
 	Where(conditions ...BooleanExpression) deleteWithWhere
+	AllRows() deleteWithWhere
+}
+
+type toDeleteJoin interface {
+	Join(table Table) deleteWithJoin
+	LeftJoin(table Table) deleteWithJoin
+}
+
+type deleteWithJoin interface {
+	On(condition BooleanExpression) deleteWithJoinOn
+}
+
+type deleteWithJoinOn interface {
+	toDeleteJoin
+	Where(conditions ...BooleanExpression) deleteWithWhere
+	AllRows() deleteWithWhere
 }
 
 type deleteWithWhere interface {
 	toDeleteWithContext
 	toDeleteFinal
+	toDeleteReturning
 	OrderBy(orderBys ...OrderBy) deleteWithOrder
 	Limit(limit int) deleteWithLimit
 }
@@ -29,12 +51,18 @@ type deleteWithWhere interface {
 type deleteWithOrder interface {
 	toDeleteWithContext
 	toDeleteFinal
+	toDeleteReturning
 	Limit(limit int) deleteWithLimit
 }
 
 type deleteWithLimit interface {
 	toDeleteWithContext
 	toDeleteFinal
+	toDeleteReturning
+}
+
+type toDeleteReturning interface {
+	Returning(fields ...Field) returningWithFields
 }
 
 type toDeleteWithContext interface {
@@ -43,6 +71,7 @@ type toDeleteWithContext interface {
 
 type toDeleteFinal interface {
 	GetSQL() (string, error)
+	GetSQLWithArgs() (string, []interface{}, error)
 	Execute() (result sql.Result, err error)
 }
 
@@ -50,11 +79,51 @@ func (d *database) DeleteFrom(table Table) deleteWithTable {
 	return deleteStatus{scope: scope{Database: d, Tables: []Table{table}}}
 }
 
+// Using adds the tables referenced by the WHERE clause, to delete the rows of the target table by their relationship.
+// It is rendered as DELETE t FROM t, u in MySQL and SQL Server, and DELETE FROM t USING u in PostgreSQL.
+func (s deleteStatus) Using(tables ...Table) deleteWithJoinOn {
+	s.scope.Tables = append(s.scope.Tables[:1:1], tables...)
+	return s
+}
+
+// Join joins the table to delete the rows of the target table by their relationship.
+// It is rendered as DELETE t FROM t JOIN u in MySQL and SQL Server, and DELETE FROM t USING u in PostgreSQL.
+func (s deleteStatus) Join(table Table) deleteWithJoin {
+	return s.join("", table)
+}
+
+// LeftJoin joins the table with LEFT JOIN, which is not supported as the first join without Using in PostgreSQL.
+func (s deleteStatus) LeftJoin(table Table) deleteWithJoin {
+	return s.join("LEFT ", table)
+}
+
+func (s deleteStatus) join(prefix string, table Table) deleteWithJoin {
+	s.scope.lastJoin = &join{
+		previous: s.scope.lastJoin,
+		prefix:   prefix,
+		table:    table,
+	}
+	return s
+}
+
+func (s deleteStatus) On(condition BooleanExpression) deleteWithJoinOn {
+	join := *s.scope.lastJoin
+	join.on = condition
+	s.scope.lastJoin = &join
+	return s
+}
+
 func (s deleteStatus) Where(conditions ...BooleanExpression) deleteWithWhere {
 	s.where = And(conditions...)
 	return s
 }
 
+// AllRows deletes all the rows explicitly, which is required in safe mode if there are no conditions.
+func (s deleteStatus) AllRows() deleteWithWhere {
+	s.allRows = true
+	return s
+}
+
 func (s deleteStatus) OrderBy(orderBys ...OrderBy) deleteWithOrder {
 	s.orderBys = orderBys
 	return s
@@ -66,15 +135,95 @@ func (s deleteStatus) Limit(limit int) deleteWithLimit {
 }
 
 func (s deleteStatus) GetSQL() (string, error) {
+	sql, _, err := s.GetSQLWithArgs()
+	return sql, err
+}
+
+func (s deleteStatus) GetSQLWithArgs() (string, []interface{}, error) {
+	args := newArgList(s.scope.Database)
+	sql, err := s.buildSQL(args)
+	if err != nil {
+		return "", nil, err
+	}
+	return sql, args.getValues(), nil
+}
+
+func (s deleteStatus) buildSQL(args *argList) (string, error) {
+	s.scope.args = args
+	if err := checkSafeMode(s.scope, "DELETE", s.where, s.allRows); err != nil {
+		return "", err
+	}
 	var sb strings.Builder
 	sb.Grow(128)
 
-	sb.WriteString("DELETE FROM ")
-	sb.WriteString(s.scope.Tables[0].GetSQL(s.scope))
+	usingTables := s.scope.Tables[1:]
+	joins := getJoins(s.scope.lastJoin)
+	isMultiTable := len(usingTables) > 0 || len(joins) > 0
+	if isMultiTable && (len(s.orderBys) > 0 || s.limit != nil) {
+		return "", errors.New("ORDER BY and LIMIT are not supported in DELETE with multiple tables")
+	}
+
+	dialect := s.scope.getDialect()
+	top, limitSql, err := dialect.UpdateLimit(getLimit(s.limit), len(s.orderBys) > 0)
+	if err != nil {
+		return "", err
+	}
+	if top != "" && len(s.orderBys) > 0 {
+		target, err := appendOrderedTarget(&sb, s.scope, top, s.where, s.orderBys)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString("DELETE FROM ")
+		sb.WriteString(target)
+		return sb.String(), nil
+	}
+	sb.WriteString("DELETE")
+	sb.WriteString(top)
+
+	where := s.where
+	tableSql := s.scope.Tables[0].GetSQL(s.scope)
+	if !isMultiTable {
+		sb.WriteString(" FROM ")
+		sb.WriteString(tableSql)
+	} else {
+		switch dialect.MultiTableStyle() {
+		case MultiTableFrom:
+			// DELETE FROM t USING u WHERE <condition of joining u> AND ...
+			if len(usingTables) == 0 {
+				if joins[0].prefix != "" || joins[0].on == nil {
+					return "", fmt.Errorf("%sJOIN is not supported as the first join of DELETE in %s", joins[0].prefix, dialect.Name())
+				}
+				where = andJoinCondition(joins[0].on, where)
+				usingTables = []Table{joins[0].table}
+				joins = joins[1:]
+			}
+			sb.WriteString(" FROM ")
+			sb.WriteString(tableSql)
+			sb.WriteString(" USING ")
+			sb.WriteString(commaTables(s.scope, usingTables, ""))
+		case MultiTableUpdateFrom:
+			return "", fmt.Errorf("DELETE with multiple tables is not supported in %s", dialect.Name())
+		default:
+			// DELETE t FROM t, u JOIN v ON ...
+			sb.WriteString(" ")
+			sb.WriteString(tableSql)
+			sb.WriteString(" FROM ")
+			sb.WriteString(commaTables(s.scope, s.scope.Tables, ""))
+		}
+		if err := appendJoins(&sb, s.scope, joins, ""); err != nil {
+			return "", err
+		}
+	}
+
+	if err := appendWhere(&sb, s.scope, where); err != nil {
+		return "", err
+	}
 
-	if err := appendWhere(&sb, s.scope, s.where); err != nil {
+	returningSql, err := getReturningSQL(s.scope, s.returning)
+	if err != nil {
 		return "", err
 	}
+	sb.WriteString(returningSql)
 
 	if len(s.orderBys) > 0 {
 		orderBySql, err := commaOrderBys(s.scope, s.orderBys)
@@ -85,23 +234,28 @@ func (s deleteStatus) GetSQL() (string, error) {
 		sb.WriteString(orderBySql)
 	}
 
-	if s.limit != nil {
-		sb.WriteString(" LIMIT ")
-		sb.WriteString(strconv.Itoa(*s.limit))
-	}
+	sb.WriteString(limitSql)
 
 	return sb.String(), nil
 }
 
+func (s deleteStatus) Returning(fields ...Field) returningWithFields {
+	s.returning = fields
+	return returningStatus{statement: s, database: s.scope.Database}
+}
+
 func (s deleteStatus) WithContext(ctx context.Context) toDeleteFinal {
 	s.ctx = ctx
 	return s
 }
 
 func (s deleteStatus) Execute() (sql.Result, error) {
-	sqlString, err := s.GetSQL()
+	if err := s.scope.checkShardKey(s.ctx); err != nil {
+		return nil, err
+	}
+	sqlString, args, err := s.GetSQLWithArgs()
 	if err != nil {
 		return nil, err
 	}
-	return s.scope.Database.ExecuteContext(s.ctx, sqlString)
+	return s.scope.Database.ExecuteContext(s.ctx, sqlString, args...)
 }
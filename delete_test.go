@@ -37,3 +37,47 @@ func TestDelete(t *testing.T) {
 	}
 	assertLastSql(t, "DELETE FROM `table1` WHERE #1#")
 }
+
+func TestDeleteMultiTable(t *testing.T) {
+	db := newMockDatabase()
+	_, _ = db.DeleteFrom(Table1).Using(table2).Where(field1.Equals(field3), field3.GreaterThan(1)).Execute()
+	assertLastSql(t, "DELETE `table1` FROM `table1`, `table2`"+
+		" WHERE `table1`.`field1` = `table2`.`field3` AND `table2`.`field3` > 1")
+
+	_, _ = db.DeleteFrom(Table1).Join(table2).On(field1.Equals(field3)).Where(field3.GreaterThan(1)).Execute()
+	assertLastSql(t, "DELETE `table1` FROM `table1` JOIN `table2` ON `table1`.`field1` = `table2`.`field3`"+
+		" WHERE `table2`.`field3` > 1")
+
+	_, _ = db.DeleteFrom(Table1).LeftJoin(table2).On(field1.Equals(field3)).Where(field3.IsNull()).Execute()
+	assertLastSql(t, "DELETE `table1` FROM `table1` LEFT JOIN `table2` ON `table1`.`field1` = `table2`.`field3`"+
+		" WHERE `table2`.`field3` IS NULL")
+
+	if _, err := db.DeleteFrom(Table1).Using(table2).Where(field1.Equals(field3)).Limit(1).Execute(); err == nil {
+		t.Error("should get error here")
+	}
+
+	postgres := UseWithDialect(nil, dialectPostgres)
+	sql, _ := postgres.DeleteFrom(Table1).Using(table2).Where(field1.Equals(field3)).GetSQL()
+	assertEqual(t, sql, `DELETE FROM "table1" USING "table2" WHERE "table1"."field1" = "table2"."field3"`)
+
+	sql, _ = postgres.DeleteFrom(Table1).Join(table2).On(field1.Equals(field3)).Where(True()).GetSQL()
+	assertEqual(t, sql, `DELETE FROM "table1" USING "table2" WHERE "table1"."field1" = "table2"."field3"`)
+
+	sql, _ = postgres.DeleteFrom(Table1).Join(table2).On(field1.Equals(field3)).Where(field3.GreaterThan(1)).
+		Returning(field1).GetSQL()
+	assertEqual(t, sql, `DELETE FROM "table1" USING "table2" WHERE "table1"."field1" = "table2"."field3"`+
+		` AND "table2"."field3" > 1 RETURNING "table1"."field1"`)
+
+	if _, err := postgres.DeleteFrom(Table1).LeftJoin(table2).On(field1.Equals(field3)).Where(True()).GetSQL(); err == nil {
+		t.Error("should get error here")
+	}
+
+	mssql := UseWithDialect(nil, dialectMSSQL)
+	sql, _ = mssql.DeleteFrom(Table1).Join(table2).On(field1.Equals(field3)).Where(True()).GetSQL()
+	assertEqual(t, sql, "DELETE [table1] FROM [table1] JOIN [table2] ON [table1].[field1] = [table2].[field3]")
+
+	sqlite := UseWithDialect(nil, dialectSqlite3)
+	if _, err := sqlite.DeleteFrom(Table1).Using(table2).Where(field1.Equals(field3)).GetSQL(); err == nil {
+		t.Error("should get error here")
+	}
+}
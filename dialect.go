@@ -1,30 +1,780 @@
 package sqlingo
 
-type dialect int
+import (
+	"database/sql/driver"
+	"encoding/hex"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LockMode is the row locking mode of a SELECT statement.
+type LockMode int
+
+const (
+	LockNone LockMode = iota
+	LockInShareMode
+	LockForUpdate
+	LockForUpdateNoWait
+	LockForUpdateSkipLocked
+)
+
+// Dialect is the interface of an SQL dialect, which renders the database-specific parts of statements.
+// Embed one of the built-in dialects to support a similar database by overriding only what differs.
+type Dialect interface {
+	// Name returns the name of the dialect.
+	Name() string
+	// QuoteIdentifier quotes a table or field name.
+	QuoteIdentifier(identifier string) string
+	// QuoteString renders a string literal.
+	QuoteString(s string) string
+	// FormatBytes renders a binary literal.
+	FormatBytes(b []byte) string
+	// FormatBool renders a boolean literal.
+	FormatBool(b bool) string
+	// FormatTime renders a time literal.
+	FormatTime(t time.Time) string
+	// Placeholder returns the placeholder of the n-th bound argument, starting from 1.
+	Placeholder(n int) string
+	// LimitOffset renders the LIMIT and OFFSET clause of a SELECT statement. limit is negative if not specified,
+	// and ordered tells whether the statement has an ORDER BY clause.
+	LimitOffset(limit int, offset int, ordered bool) string
+	// UpdateLimit renders the row limit of an UPDATE or DELETE statement. limit is negative if not specified,
+	// and ordered tells whether the statement has an ORDER BY clause.
+	// top follows the UPDATE or DELETE keyword, and suffix is appended to the statement.
+	// If top is not empty, the ordered rows are chosen by a common table expression, as ORDER BY cannot follow TOP.
+	UpdateLimit(limit int, ordered bool) (top string, suffix string, err error)
+	// Lock renders the locking clause of a SELECT statement.
+	Lock(mode LockMode) string
+	// TableHint renders the hint following each table of a SELECT statement to lock rows.
+	TableHint(mode LockMode) string
+	// Upsert renders an INSERT statement which updates or ignores the conflicting rows.
+	Upsert(statement UpsertStatement) (string, error)
+	// InsertedValue refers to the value proposed for insertion of the quoted field, in the assignments of Upsert.
+	InsertedValue(field string) string
+	// Returning renders the RETURNING clause of an INSERT, UPDATE or DELETE statement.
+	Returning(fields string) (string, error)
+	// FunctionName translates the name of a function, which is written in MySQL flavor.
+	FunctionName(name string) string
+	// BooleanValue renders a condition as a value, which could be selected.
+	BooleanValue(condition string) string
+	// Condition renders a value, such as a boolean field, as a condition.
+	Condition(value string) string
+	// BooleanConstant renders TRUE or FALSE as a condition.
+	BooleanConstant(value bool) string
+	// Savepoint renders the statements to create a savepoint, to roll back to it, and to release it.
+	// release is empty if savepoints are not released explicitly.
+	Savepoint(name string) (savepoint string, rollback string, release string)
+	// StringPosition returns the function finding the 1-based position of a substring in a string,
+	// and whether the substring is its first argument.
+	StringPosition() (function string, substringFirst bool)
+	// DefaultValue renders the default value of a field in the VALUES clause.
+	DefaultValue() (string, error)
+	// AutoIncrementMode tells how the auto-increment ids generated by a multi-row INSERT are determined.
+	AutoIncrementMode() AutoIncrementMode
+	// MultiTableStyle tells how UPDATE and DELETE statements with multiple tables are rendered.
+	MultiTableStyle() MultiTableStyle
+	// BulkLoadMethod tells how BulkLoad streams the rows, if supported by the driver.
+	BulkLoadMethod() BulkLoadMethod
+	// NullsLast tells whether NULL is sorted after the other values in ascending order.
+	NullsLast() bool
+}
+
+// AutoIncrementMode is the way to determine the auto-increment ids generated by a multi-row INSERT.
+type AutoIncrementMode int
+
+const (
+	// AutoIncrementUnsupported means the ids cannot be determined.
+	AutoIncrementUnsupported AutoIncrementMode = iota
+	// AutoIncrementLastInsertID means the ids are consecutive from the one returned by LastInsertId.
+	AutoIncrementLastInsertID
+	// AutoIncrementReturning means the ids are returned by the RETURNING clause.
+	AutoIncrementReturning
+)
+
+// MultiTableStyle is the syntax of UPDATE and DELETE statements with multiple tables.
+type MultiTableStyle int
+
+const (
+	// MultiTableJoin renders UPDATE t JOIN u ON ... SET ... and DELETE t FROM t, u JOIN v ON ...
+	MultiTableJoin MultiTableStyle = iota
+	// MultiTableFrom renders UPDATE t SET ... FROM u WHERE ... and DELETE FROM t USING u WHERE ...,
+	// in which the condition of the first join is moved into the WHERE clause.
+	MultiTableFrom
+	// MultiTableFromTarget renders UPDATE t SET ... FROM t JOIN u ON ... and DELETE t FROM t, u JOIN v ON ...
+	MultiTableFromTarget
+	// MultiTableUpdateFrom renders UPDATE as MultiTableFrom, and DELETE with multiple tables is not supported.
+	MultiTableUpdateFrom
+)
+
+// BulkLoadMethod is the way to stream the rows in BulkLoad.
+type BulkLoadMethod int
 
 const (
-	dialectUnknown dialect = iota
-	dialectMySQL
-	dialectSqlite3
-	dialectPostgres
-	dialectMSSQL
+	// BulkLoadInsert inserts the rows by multi-row INSERT statements in chunks.
+	BulkLoadInsert BulkLoadMethod = iota
+	// BulkLoadLoadData streams the rows by LOAD DATA LOCAL INFILE.
+	BulkLoadLoadData
+	// BulkLoadCopy streams the rows by COPY ... FROM STDIN.
+	BulkLoadCopy
+)
+
+// UpsertStatement is the rendered parts of an INSERT statement which handles the conflicting rows.
+type UpsertStatement struct {
+	// Method is INSERT or REPLACE.
+	Method string
+	Table  string
+	// Fields are the quoted fields to insert.
+	Fields []string
+	// Values are the rendered rows, such as "(1, 2), (3, 4)".
+	Values string
+	// Select is the rendered SELECT statement which provides the rows instead of Values, if not empty.
+	Select string
+	// ConflictFields are the quoted fields of the conflict target, which could be empty.
+	ConflictFields []string
+	// Assignments are the assignments to update the conflicting row, or empty to ignore it.
+	Assignments string
+}
+
+// source renders the VALUES clause, or the SELECT statement.
+func (s UpsertStatement) source() string {
+	if s.Select != "" {
+		return s.Select
+	}
+	return "VALUES " + s.Values
+}
+
+// MySQLDialect is the dialect of MySQL.
+type MySQLDialect struct {
+	// RowAlias makes the upsert refer to the inserted row by an alias, instead of the VALUES() function
+	// which is deprecated since MySQL 8.0.20.
+	RowAlias bool
+}
+
+func (MySQLDialect) Name() string {
+	return "mysql"
+}
+
+func (MySQLDialect) QuoteIdentifier(identifier string) string {
+	return "`" + identifier + "`"
+}
+
+func (MySQLDialect) QuoteString(s string) string {
+	return quoteString(s)
+}
+
+func (MySQLDialect) FormatBytes(b []byte) string {
+	return "X'" + hex.EncodeToString(b) + "'"
+}
+
+func (MySQLDialect) FormatBool(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+func (MySQLDialect) FormatTime(t time.Time) string {
+	return quoteString(t.Format("2006-01-02 15:04:05.000000"))
+}
+
+func (MySQLDialect) Placeholder(n int) string {
+	return "?"
+}
+
+func (MySQLDialect) LimitOffset(limit int, offset int, ordered bool) string {
+	var sb strings.Builder
+	if limit >= 0 {
+		sb.WriteString(" LIMIT ")
+		sb.WriteString(strconv.Itoa(limit))
+	}
+	if offset != 0 {
+		sb.WriteString(" OFFSET ")
+		sb.WriteString(strconv.Itoa(offset))
+	}
+	return sb.String()
+}
+
+func (d MySQLDialect) UpdateLimit(limit int, ordered bool) (top string, suffix string, err error) {
+	return "", d.LimitOffset(limit, 0, false), nil
+}
+
+func (MySQLDialect) Lock(mode LockMode) string {
+	switch mode {
+	case LockInShareMode:
+		return " LOCK IN SHARE MODE"
+	case LockForUpdate:
+		return " FOR UPDATE"
+	case LockForUpdateNoWait:
+		return " FOR UPDATE NOWAIT"
+	case LockForUpdateSkipLocked:
+		return " FOR UPDATE SKIP LOCKED"
+	default:
+		return ""
+	}
+}
+
+func (MySQLDialect) TableHint(mode LockMode) string {
+	return ""
+}
+
+// Upsert ignores the conflict target, any unique key of the table could be conflicting in MySQL.
+func (d MySQLDialect) Upsert(statement UpsertStatement) (string, error) {
+	if statement.Assignments == "" {
+		return insertSQL(statement.Method+" IGNORE", statement.Table, statement.Fields, statement.source()), nil
+	}
+	if !d.RowAlias {
+		return insertSQL(statement.Method, statement.Table, statement.Fields, statement.source()) +
+			" ON DUPLICATE KEY UPDATE " + statement.Assignments, nil
+	}
+	var sqlString string
+	if statement.Select != "" {
+		// the row alias is not allowed after SELECT, use a derived table instead
+		sqlString = insertSQL(statement.Method, statement.Table, statement.Fields,
+			"SELECT * FROM ("+statement.Select+") AS `new` ("+strings.Join(statement.Fields, ", ")+")")
+	} else {
+		sqlString = insertSQL(statement.Method, statement.Table, statement.Fields, statement.source()) + " AS `new`"
+	}
+	return sqlString + " ON DUPLICATE KEY UPDATE " + statement.Assignments, nil
+}
+
+func (d MySQLDialect) InsertedValue(field string) string {
+	if d.RowAlias {
+		return "`new`." + field
+	}
+	return "VALUES(" + field + ")"
+}
+
+func (MySQLDialect) Returning(fields string) (string, error) {
+	return "", errors.New("returning is not supported by mysql")
+}
+
+func (MySQLDialect) FunctionName(name string) string {
+	return name
+}
+
+func (MySQLDialect) BooleanValue(condition string) string {
+	return condition
+}
+
+func (MySQLDialect) Condition(value string) string {
+	return value
+}
+
+func (MySQLDialect) BooleanConstant(value bool) string {
+	if value {
+		return "TRUE"
+	}
+	return "FALSE"
+}
+
+func (MySQLDialect) Savepoint(name string) (savepoint string, rollback string, release string) {
+	return "SAVEPOINT " + name, "ROLLBACK TO SAVEPOINT " + name, "RELEASE SAVEPOINT " + name
+}
+
+func (MySQLDialect) StringPosition() (function string, substringFirst bool) {
+	return "LOCATE", true
+}
+
+func (MySQLDialect) DefaultValue() (string, error) {
+	return "DEFAULT", nil
+}
+
+// AutoIncrementMode relies on the consecutive ids of a multi-row INSERT, assuming auto_increment_increment is 1.
+func (MySQLDialect) AutoIncrementMode() AutoIncrementMode {
+	return AutoIncrementLastInsertID
+}
+
+func (MySQLDialect) MultiTableStyle() MultiTableStyle {
+	return MultiTableJoin
+}
+
+func (MySQLDialect) BulkLoadMethod() BulkLoadMethod {
+	return BulkLoadLoadData
+}
+
+func (MySQLDialect) NullsLast() bool {
+	return false
+}
+
+// PostgreSQLDialect is the dialect of PostgreSQL, assuming standard_conforming_strings is on.
+type PostgreSQLDialect struct {
+	MySQLDialect
+}
+
+func (PostgreSQLDialect) Name() string {
+	return "postgres"
+}
+
+func (PostgreSQLDialect) QuoteIdentifier(identifier string) string {
+	return "\"" + identifier + "\""
+}
+
+func (PostgreSQLDialect) QuoteString(s string) string {
+	return quoteStandardString(s)
+}
+
+func (PostgreSQLDialect) FormatBytes(b []byte) string {
+	return "'\\x" + hex.EncodeToString(b) + "'::bytea"
+}
+
+func (PostgreSQLDialect) FormatBool(b bool) string {
+	if b {
+		return "TRUE"
+	}
+	return "FALSE"
+}
+
+func (PostgreSQLDialect) FormatTime(t time.Time) string {
+	return quoteStandardString(t.Format("2006-01-02 15:04:05.000000-07:00"))
+}
+
+func (PostgreSQLDialect) Placeholder(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+func (d PostgreSQLDialect) Lock(mode LockMode) string {
+	if mode == LockInShareMode {
+		return " FOR SHARE"
+	}
+	return d.MySQLDialect.Lock(mode)
+}
+
+func (PostgreSQLDialect) Upsert(statement UpsertStatement) (string, error) {
+	if len(statement.ConflictFields) == 0 && statement.Assignments != "" {
+		return "", errors.New("DO UPDATE without conflict fields is not supported by postgres, specify them with OnConflict")
+	}
+	return onConflict(statement), nil
+}
+
+func (PostgreSQLDialect) InsertedValue(field string) string {
+	return "EXCLUDED." + field
+}
+
+func (PostgreSQLDialect) Returning(fields string) (string, error) {
+	return " RETURNING " + fields, nil
+}
+
+func (PostgreSQLDialect) StringPosition() (function string, substringFirst bool) {
+	return "STRPOS", false
+}
+
+func (PostgreSQLDialect) AutoIncrementMode() AutoIncrementMode {
+	return AutoIncrementReturning
+}
+
+func (PostgreSQLDialect) MultiTableStyle() MultiTableStyle {
+	return MultiTableFrom
+}
+
+func (PostgreSQLDialect) BulkLoadMethod() BulkLoadMethod {
+	return BulkLoadCopy
+}
+
+func (PostgreSQLDialect) NullsLast() bool {
+	return true
+}
+
+// SQLiteDialect is the dialect of SQLite.
+type SQLiteDialect struct {
+	MySQLDialect
+}
+
+func (SQLiteDialect) Name() string {
+	return "sqlite3"
+}
+
+func (SQLiteDialect) QuoteIdentifier(identifier string) string {
+	return "\"" + identifier + "\""
+}
+
+func (SQLiteDialect) QuoteString(s string) string {
+	return quoteStandardString(s)
+}
+
+func (SQLiteDialect) FormatTime(t time.Time) string {
+	return quoteStandardString(t.Format("2006-01-02 15:04:05.000000"))
+}
+
+func (SQLiteDialect) Lock(mode LockMode) string {
+	// SQLite locks the whole database on writing, there is no row locking
+	return ""
+}
+
+func (SQLiteDialect) Upsert(statement UpsertStatement) (string, error) {
+	if statement.Select != "" {
+		// ON CONFLICT would be parsed as the join constraint of the SELECT statement without WHERE clause
+		statement.Select = "SELECT * FROM (" + statement.Select + ") WHERE true"
+	}
+	return onConflict(statement), nil
+}
+
+func (SQLiteDialect) InsertedValue(field string) string {
+	return "excluded." + field
+}
+
+// Returning requires SQLite 3.35.0 or later.
+func (SQLiteDialect) Returning(fields string) (string, error) {
+	return " RETURNING " + fields, nil
+}
+
+func (SQLiteDialect) StringPosition() (function string, substringFirst bool) {
+	return "INSTR", false
+}
+
+func (SQLiteDialect) DefaultValue() (string, error) {
+	return "", errors.New("DEFAULT in VALUES is not supported by sqlite3, insert the models with and without default values separately")
+}
+
+func (SQLiteDialect) AutoIncrementMode() AutoIncrementMode {
+	return AutoIncrementReturning
+}
+
+// MultiTableStyle requires SQLite 3.33.0 or later for UPDATE ... FROM.
+func (SQLiteDialect) MultiTableStyle() MultiTableStyle {
+	return MultiTableUpdateFrom
+}
+
+func (SQLiteDialect) BulkLoadMethod() BulkLoadMethod {
+	return BulkLoadInsert
+}
+
+// MSSQLDialect is the dialect of Microsoft SQL Server.
+type MSSQLDialect struct {
+	MySQLDialect
+}
+
+func (MSSQLDialect) Name() string {
+	return "mssql"
+}
+
+func (MSSQLDialect) QuoteIdentifier(identifier string) string {
+	return "[" + identifier + "]"
+}
+
+func (MSSQLDialect) QuoteString(s string) string {
+	return "N" + quoteStandardString(s)
+}
+
+func (MSSQLDialect) FormatBytes(b []byte) string {
+	return "0x" + hex.EncodeToString(b)
+}
+
+func (MSSQLDialect) FormatTime(t time.Time) string {
+	// ISO 8601 is independent of DATEFORMAT, and DATETIME accepts at most 3 fractional digits
+	return quoteStandardString(t.Format("2006-01-02T15:04:05.000"))
+}
+
+func (MSSQLDialect) Placeholder(n int) string {
+	return "@p" + strconv.Itoa(n)
+}
+
+func (MSSQLDialect) LimitOffset(limit int, offset int, ordered bool) string {
+	if limit < 0 && offset == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	if !ordered {
+		// OFFSET FETCH is a part of ORDER BY clause
+		sb.WriteString(" ORDER BY (SELECT NULL)")
+	}
+	sb.WriteString(" OFFSET ")
+	sb.WriteString(strconv.Itoa(offset))
+	sb.WriteString(" ROWS")
+	if limit >= 0 {
+		sb.WriteString(" FETCH NEXT ")
+		sb.WriteString(strconv.Itoa(limit))
+		sb.WriteString(" ROWS ONLY")
+	}
+	return sb.String()
+}
+
+func (MSSQLDialect) UpdateLimit(limit int, ordered bool) (top string, suffix string, err error) {
+	if limit < 0 {
+		if ordered {
+			return "", "", errors.New("ORDER BY without limit is not supported in UPDATE and DELETE of mssql")
+		}
+		return "", "", nil
+	}
+	return " TOP (" + strconv.Itoa(limit) + ")", "", nil
+}
+
+func (MSSQLDialect) Lock(mode LockMode) string {
+	return ""
+}
+
+func (MSSQLDialect) TableHint(mode LockMode) string {
+	switch mode {
+	case LockInShareMode:
+		return " WITH (HOLDLOCK, ROWLOCK)"
+	case LockForUpdate:
+		return " WITH (UPDLOCK, ROWLOCK)"
+	case LockForUpdateNoWait:
+		return " WITH (UPDLOCK, ROWLOCK, NOWAIT)"
+	case LockForUpdateSkipLocked:
+		return " WITH (UPDLOCK, ROWLOCK, READPAST)"
+	default:
+		return ""
+	}
+}
+
+// Upsert renders a MERGE statement, matching the rows by the conflict target.
+func (MSSQLDialect) Upsert(statement UpsertStatement) (string, error) {
+	if len(statement.ConflictFields) == 0 {
+		return "", errors.New("upsert without conflict fields is not supported by mssql")
+	}
+
+	var sb strings.Builder
+	sb.Grow(256)
+	sb.WriteString("MERGE INTO ")
+	sb.WriteString(statement.Table)
+	// HOLDLOCK prevents concurrent MERGE statements from inserting the same row
+	sb.WriteString(" WITH (HOLDLOCK) USING (")
+	sb.WriteString(statement.source())
+	sb.WriteString(") AS [source] (")
+	sb.WriteString(strings.Join(statement.Fields, ", "))
+	sb.WriteString(") ON ")
+	for i, field := range statement.ConflictFields {
+		if i > 0 {
+			sb.WriteString(" AND ")
+		}
+		sb.WriteString(statement.Table + "." + field + " = [source]." + field)
+	}
+	if statement.Assignments != "" {
+		sb.WriteString(" WHEN MATCHED THEN UPDATE SET ")
+		sb.WriteString(statement.Assignments)
+	}
+	sb.WriteString(" WHEN NOT MATCHED THEN INSERT (")
+	sb.WriteString(strings.Join(statement.Fields, ", "))
+	sb.WriteString(") VALUES (")
+	for i, field := range statement.Fields {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString("[source].")
+		sb.WriteString(field)
+	}
+	// MERGE must be terminated by a semicolon
+	sb.WriteString(");")
+	return sb.String(), nil
+}
+
+func (MSSQLDialect) InsertedValue(field string) string {
+	return "[source]." + field
+}
+
+func (MSSQLDialect) Returning(fields string) (string, error) {
+	return "", errors.New("returning is not supported by mssql")
+}
+
+// Savepoint uses SAVE TRANSACTION, which is released with the outer transaction.
+func (MSSQLDialect) Savepoint(name string) (savepoint string, rollback string, release string) {
+	return "SAVE TRANSACTION " + name, "ROLLBACK TRANSACTION " + name, ""
+}
+
+func (MSSQLDialect) AutoIncrementMode() AutoIncrementMode {
+	return AutoIncrementUnsupported
+}
+
+func (MSSQLDialect) MultiTableStyle() MultiTableStyle {
+	return MultiTableFromTarget
+}
+
+func (MSSQLDialect) BulkLoadMethod() BulkLoadMethod {
+	return BulkLoadInsert
+}
+
+// BooleanValue uses CASE, as a condition is not a value in SQL Server.
+func (MSSQLDialect) BooleanValue(condition string) string {
+	return "CASE WHEN " + condition + " THEN 1 ELSE 0 END"
+}
+
+// Condition compares the value with 0, as a bit value is not a condition in SQL Server.
+func (MSSQLDialect) Condition(value string) string {
+	return value + " <> 0"
+}
+
+// BooleanConstant uses comparisons, as there are no boolean literals in SQL Server.
+func (MSSQLDialect) BooleanConstant(value bool) string {
+	if value {
+		return "1 = 1"
+	}
+	return "1 = 0"
+}
+
+func (MSSQLDialect) FunctionName(name string) string {
+	switch name {
+	case "IF":
+		return "IIF"
+	case "IFNULL":
+		return "ISNULL"
+	case "CHAR_LENGTH":
+		return "LEN"
+	case "LOCATE":
+		return "CHARINDEX"
+	default:
+		return name
+	}
+}
+
+// unknownDialect is used for unknown drivers. It quotes identifiers in standard SQL and renders values like MySQL.
+type unknownDialect struct {
+	MySQLDialect
+}
+
+func (unknownDialect) Name() string {
+	return "unknown"
+}
+
+func (unknownDialect) QuoteIdentifier(identifier string) string {
+	return "\"" + identifier + "\""
+}
+
+func (unknownDialect) Returning(fields string) (string, error) {
+	return "", errors.New("returning is not supported by unknown dialect")
+}
+
+func (unknownDialect) BulkLoadMethod() BulkLoadMethod {
+	return BulkLoadInsert
+}
+
+func insertSQL(method string, table string, fields []string, source string) string {
+	return method + " INTO " + table + " (" + strings.Join(fields, ", ") + ") " + source
+}
+
+func onConflict(statement UpsertStatement) string {
+	sqlString := insertSQL(statement.Method, statement.Table, statement.Fields, statement.source()) + " ON CONFLICT"
+	if len(statement.ConflictFields) > 0 {
+		sqlString += " (" + strings.Join(statement.ConflictFields, ", ") + ")"
+	}
+	if statement.Assignments == "" {
+		return sqlString + " DO NOTHING"
+	}
+	return sqlString + " DO UPDATE SET " + statement.Assignments
+}
 
-	dialectCount
+var (
+	dialectUnknown  Dialect = unknownDialect{}
+	dialectMySQL    Dialect = MySQLDialect{}
+	dialectSqlite3  Dialect = SQLiteDialect{}
+	dialectPostgres Dialect = PostgreSQLDialect{}
+	dialectMSSQL    Dialect = MSSQLDialect{}
 )
 
-type dialectArray [dialectCount]string
-
-func getDialectFromDriverName(driverName string) dialect {
-	switch driverName {
-	case "mysql":
-		return dialectMySQL
-	case "sqlite3":
-		return dialectSqlite3
-	case "postgres":
-		return dialectPostgres
-	case "sqlserver", "mssql":
-		return dialectMSSQL
+// builtinDialects are the built-in dialects, in which the tables and fields precompute their quoted names.
+var builtinDialects = [...]Dialect{dialectUnknown, dialectMySQL, dialectSqlite3, dialectPostgres, dialectMSSQL}
+
+type dialectArray [len(builtinDialects)]string
+
+// getBuiltinDialectIndex returns the index of the dialect in builtinDialects, or -1 if it's a custom dialect.
+func getBuiltinDialectIndex(dialect Dialect) int {
+	switch dialect.(type) {
+	case unknownDialect:
+		return 0
+	case MySQLDialect:
+		return 1
+	case SQLiteDialect:
+		return 2
+	case PostgreSQLDialect:
+		return 3
+	case MSSQLDialect:
+		return 4
 	default:
+		return -1
+	}
+}
+
+func quoteIdentifier(identifier string) (result dialectArray) {
+	for i, dialect := range builtinDialects {
+		result[i] = dialect.QuoteIdentifier(identifier)
+	}
+	return
+}
+
+var (
+	driverDialectsMutex sync.RWMutex
+	driverDialects      = map[string]Dialect{
+		"mysql":            dialectMySQL,
+		"nrmysql":          dialectMySQL,
+		"sqlite3":          dialectSqlite3,
+		"sqlite":           dialectSqlite3,
+		"nrsqlite3":        dialectSqlite3,
+		"libsql":           dialectSqlite3,
+		"postgres":         dialectPostgres,
+		"postgresql":       dialectPostgres,
+		"pgx":              dialectPostgres,
+		"pgx/v4":           dialectPostgres,
+		"pgx/v5":           dialectPostgres,
+		"nrpostgres":       dialectPostgres,
+		"cloudsqlpostgres": dialectPostgres,
+		"sqlserver":        dialectMSSQL,
+		"mssql":            dialectMSSQL,
+		"azuresql":         dialectMSSQL,
+	}
+)
+
+// driverPackageDialects maps the package paths of well-known drivers to their dialects.
+var driverPackageDialects = []struct {
+	pkgPath string
+	dialect Dialect
+}{
+	{"github.com/go-sql-driver/mysql", dialectMySQL},
+	{"github.com/mattn/go-sqlite3", dialectSqlite3},
+	{"modernc.org/sqlite", dialectSqlite3},
+	{"github.com/glebarez/go-sqlite", dialectSqlite3},
+	{"github.com/lib/pq", dialectPostgres},
+	{"github.com/jackc/pgx", dialectPostgres},
+	{"github.com/denisenkom/go-mssqldb", dialectMSSQL},
+	{"github.com/microsoft/go-mssqldb", dialectMSSQL},
+}
+
+// RegisterDriverDialect registers the dialect to use for the driver name, replacing the existing one if any.
+func RegisterDriverDialect(driverName string, dialect Dialect) {
+	driverDialectsMutex.Lock()
+	defer driverDialectsMutex.Unlock()
+	driverDialects[driverName] = dialect
+}
+
+func getDialectFromDriverName(driverName string) Dialect {
+	driverDialectsMutex.RLock()
+	defer driverDialectsMutex.RUnlock()
+	if dialect, ok := driverDialects[driverName]; ok {
+		return dialect
+	}
+
+	// wrapped driver names such as "otelsql-mysql-0" or "instrumented-postgres"
+	words := strings.FieldsFunc(driverName, func(r rune) bool {
+		return r == '-' || r == '_' || r == ':' || r == '.'
+	})
+	if len(words) > 1 {
+		for _, word := range words {
+			if dialect, ok := driverDialects[word]; ok {
+				return dialect
+			}
+		}
+	}
+	return dialectUnknown
+}
+
+func getDialectFromDriver(d driver.Driver) Dialect {
+	driverType := reflect.TypeOf(d)
+	if driverType == nil {
 		return dialectUnknown
 	}
+	if driverType.Kind() == reflect.Ptr {
+		driverType = driverType.Elem()
+	}
+	return getDialectFromPackagePath(driverType.PkgPath())
+}
+
+func getDialectFromPackagePath(pkgPath string) Dialect {
+	for _, item := range driverPackageDialects {
+		if pkgPath == item.pkgPath || strings.HasPrefix(pkgPath, item.pkgPath+"/") {
+			return item.dialect
+		}
+	}
+	return dialectUnknown
 }
@@ -3,7 +3,7 @@ package sqlingo
 import "testing"
 
 func TestDialect(t *testing.T) {
-	nameToDialect := map[string]dialect{
+	nameToDialect := map[string]Dialect{
 		"mysql":           dialectMySQL,
 		"sqlite3":         dialectSqlite3,
 		"postgres":        dialectPostgres,
@@ -18,3 +18,207 @@ func TestDialect(t *testing.T) {
 		}
 	}
 }
+
+type customDialect struct {
+	MySQLDialect
+}
+
+func (customDialect) QuoteIdentifier(identifier string) string {
+	return "<" + identifier + ">"
+}
+
+func (customDialect) FunctionName(name string) string {
+	return "custom_" + name
+}
+
+func TestUseWithDialect(t *testing.T) {
+	db := UseWithDialect(newMockDatabase().GetDB(), customDialect{})
+	sql, _ := db.Select(field1, Function("F", 1)).From(Table1).Where(field2.Equals("x")).Limit(1).GetSQL()
+	assertEqual(t, sql, "SELECT <field1>, custom_F(1) FROM <table1> WHERE <field2> = 'x' LIMIT 1")
+}
+
+type customPostgreSQLDialect struct {
+	PostgreSQLDialect
+}
+
+func (customPostgreSQLDialect) Name() string {
+	return "cockroach"
+}
+
+func TestEmbeddedDialect(t *testing.T) {
+	db := UseWithDialect(nil, customPostgreSQLDialect{})
+	sql, _ := db.Select(field1).From(Table1).Join(Test).On(Test.F1.Equals(field1)).Where(Test.F2.Contains("x")).GetSQL()
+	assertEqual(t, sql, `SELECT "table1"."field1" FROM "table1" JOIN "test" ON "test"."f1" = "table1"."field1" WHERE STRPOS("test"."f2", 'x') > 0`)
+	sql, _ = db.Update(Table1).Join(Test).On(Test.F1.Equals(field1)).Set(field2, Test.F2).Where(field1.Equals(1)).GetSQL()
+	assertEqual(t, sql, `UPDATE "table1" SET "field2" = "test"."f2" FROM "test" WHERE "test"."f1" = "table1"."field1" AND "table1"."field1" = 1`)
+}
+
+func TestDialectClauses(t *testing.T) {
+	postgres := UseWithDialect(nil, dialectPostgres)
+	sql, _ := postgres.Select(field1).From(Table1).Limit(10).Offset(20).LockInShareMode().GetSQL()
+	assertEqual(t, sql, `SELECT "field1" FROM "table1" LIMIT 10 OFFSET 20 FOR SHARE`)
+	if _, err := postgres.InsertInto(Table1).Fields(field1).Values(1).OnDuplicateKeyUpdate().Set(field2, 2).GetSQL(); err == nil {
+		t.Error("should get error here")
+	}
+
+	sqlite := UseWithDialect(nil, dialectSqlite3)
+	sql, _ = sqlite.Select(field1).From(Table1).ForUpdate().GetSQL()
+	assertEqual(t, sql, `SELECT "field1" FROM "table1"`)
+
+	if _, err := UseWithDialect(nil, dialectMSSQL).InsertInto(Table1).Fields(field1).Values(1).
+		OnDuplicateKeyUpdate().Set(field2, 2).GetSQL(); err == nil {
+		t.Error("should get error here")
+	}
+}
+
+func TestDialectUpsert(t *testing.T) {
+	postgres := UseWithDialect(nil, dialectPostgres)
+	sql, _ := postgres.InsertInto(Table1).Fields(field1, field2).Values(1, 2).
+		OnConflict(field1).DoUpdate().Set(field2, 3).GetSQL()
+	assertEqual(t, sql, `INSERT INTO "table1" ("field1", "field2") VALUES (1, 2) ON CONFLICT ("field1") DO UPDATE SET "field2" = 3`)
+	sql, _ = postgres.InsertInto(Table1).Fields(field1, field2).Values(1, 2).
+		OnConflict(field1, field2).DoNothing().GetSQL()
+	assertEqual(t, sql, `INSERT INTO "table1" ("field1", "field2") VALUES (1, 2) ON CONFLICT ("field1", "field2") DO NOTHING`)
+
+	sql, _ = postgres.InsertInto(Table1).Fields(field1, field2).Values(1, 2).
+		OnConflict(field1).DoUpdate().SetAllExcept(field1).GetSQL()
+	assertEqual(t, sql, `INSERT INTO "table1" ("field1", "field2") VALUES (1, 2) ON CONFLICT ("field1") DO UPDATE SET "field2" = EXCLUDED."field2"`)
+
+	sqlite := UseWithDialect(nil, dialectSqlite3)
+	sql, _ = sqlite.InsertInto(Table1).Fields(field1).Values(1).OnDuplicateKeyIgnore().GetSQL()
+	assertEqual(t, sql, `INSERT INTO "table1" ("field1") VALUES (1) ON CONFLICT DO NOTHING`)
+
+	sql, _ = sqlite.InsertInto(Table1).Fields(field1, field2).Select(sqlite.Select(field1, field2).From(Table1)).
+		OnConflict(field1).DoUpdate().SetAllExcept(field1).GetSQL()
+	assertEqual(t, sql, `INSERT INTO "table1" ("field1", "field2") SELECT * FROM (SELECT "field1", "field2" FROM "table1") WHERE true`+
+		` ON CONFLICT ("field1") DO UPDATE SET "field2" = excluded."field2"`)
+
+	mssql := UseWithDialect(nil, dialectMSSQL)
+	sql, _ = mssql.InsertInto(Table1).Fields(field1, field2).Values(1, 2).Values(3, 4).
+		OnConflict(field1).DoUpdate().Set(field2, Inserted(field2)).GetSQL()
+	assertEqual(t, sql, "MERGE INTO [table1] WITH (HOLDLOCK) USING (VALUES (1, 2), (3, 4)) AS [source] ([field1], [field2])"+
+		" ON [table1].[field1] = [source].[field1]"+
+		" WHEN MATCHED THEN UPDATE SET [field2] = [source].[field2]"+
+		" WHEN NOT MATCHED THEN INSERT ([field1], [field2]) VALUES ([source].[field1], [source].[field2]);")
+	sql, _ = mssql.InsertInto(Table1).Fields(field1, field2).Values(1, 2).
+		OnConflict(field1, field2).DoNothing().GetSQL()
+	assertEqual(t, sql, "MERGE INTO [table1] WITH (HOLDLOCK) USING (VALUES (1, 2)) AS [source] ([field1], [field2])"+
+		" ON [table1].[field1] = [source].[field1] AND [table1].[field2] = [source].[field2]"+
+		" WHEN NOT MATCHED THEN INSERT ([field1], [field2]) VALUES ([source].[field1], [source].[field2]);")
+	sql, _ = mssql.InsertInto(Table1).Fields(field1, field2).Select(mssql.Select(field1, field2).From(Table1)).
+		OnConflict(field1).DoNothing().GetSQL()
+	assertEqual(t, sql, "MERGE INTO [table1] WITH (HOLDLOCK) USING (SELECT [field1], [field2] FROM [table1]) AS [source] ([field1], [field2])"+
+		" ON [table1].[field1] = [source].[field1]"+
+		" WHEN NOT MATCHED THEN INSERT ([field1], [field2]) VALUES ([source].[field1], [source].[field2]);")
+	if _, err := mssql.InsertInto(Table1).Fields(field1).Values(1).OnDuplicateKeyIgnore().GetSQL(); err == nil {
+		t.Error("should get error here")
+	}
+}
+
+func TestDriverDialect(t *testing.T) {
+	nameToDialect := map[string]Dialect{
+		"pgx":               dialectPostgres,
+		"pgx/v5":            dialectPostgres,
+		"sqlite":            dialectSqlite3,
+		"nrpostgres":        dialectPostgres,
+		"otelsql-mysql-0":   dialectMySQL,
+		"instrumented_pgx":  dialectPostgres,
+		"my-own-driver":     dialectUnknown,
+		"sqlingo-mock-test": dialectUnknown,
+	}
+	for name, dialect := range nameToDialect {
+		if getDialectFromDriverName(name) != dialect {
+			t.Error(name)
+		}
+	}
+
+	RegisterDriverDialect("my-own-driver", customDialect{})
+	defer func() {
+		driverDialectsMutex.Lock()
+		delete(driverDialects, "my-own-driver")
+		driverDialectsMutex.Unlock()
+	}()
+	if getDialectFromDriverName("my-own-driver") != (customDialect{}) {
+		t.Error()
+	}
+
+	pkgPathToDialect := map[string]Dialect{
+		"github.com/go-sql-driver/mysql":  dialectMySQL,
+		"github.com/jackc/pgx/v5/stdlib":  dialectPostgres,
+		"modernc.org/sqlite":              dialectSqlite3,
+		"github.com/microsoft/go-mssqldb": dialectMSSQL,
+		"github.com/lib/pqx":              dialectUnknown,
+		"github.com/lqs/sqlingo":          dialectUnknown,
+	}
+	for pkgPath, dialect := range pkgPathToDialect {
+		if getDialectFromPackagePath(pkgPath) != dialect {
+			t.Error(pkgPath)
+		}
+	}
+	if getDialectFromDriver(mockDriver{}) != dialectUnknown {
+		t.Error()
+	}
+	if Use("sqlingo-mock", newMockDatabase().GetDB()).(*database).dialect != dialectUnknown {
+		t.Error()
+	}
+}
+
+func TestMSSQLDialect(t *testing.T) {
+	db := UseWithDialect(nil, dialectMSSQL)
+
+	sql, _ := db.Select(field1).From(Table1).Limit(10).GetSQL()
+	assertEqual(t, sql, "SELECT [field1] FROM [table1] ORDER BY (SELECT NULL) OFFSET 0 ROWS FETCH NEXT 10 ROWS ONLY")
+	sql, _ = db.Select(field1).From(Table1).OrderBy(field1.Desc()).Limit(10).Offset(20).GetSQL()
+	assertEqual(t, sql, "SELECT [field1] FROM [table1] ORDER BY [field1] DESC OFFSET 20 ROWS FETCH NEXT 10 ROWS ONLY")
+	sql, _ = db.Select(field1, field3).From(Table1).Join(table2).On(field1.Equals(field3)).ForUpdate().GetSQL()
+	assertEqual(t, sql, "SELECT [table1].[field1], [table2].[field3] FROM [table1] WITH (UPDLOCK, ROWLOCK)"+
+		" JOIN [table2] WITH (UPDLOCK, ROWLOCK) ON [table1].[field1] = [table2].[field3]")
+	sql, _ = db.Select(field1.If(1, 2), field2.IfNull(0), Test.F2.Contains("x")).From(Table1).ForUpdateSkipLocked().GetSQL()
+	assertEqual(t, sql, "SELECT IIF([field1] <> 0, 1, 2), ISNULL([field2], 0), CASE WHEN CHARINDEX(N'x', [test].[f2]) > 0 THEN 1 ELSE 0 END"+
+		" FROM [table1] WITH (UPDLOCK, ROWLOCK, READPAST)")
+	sql, _ = db.Select(field1.In(1, 2).As("c"), field2.Between(1, 2), True()).From(Table1).GetSQL()
+	assertEqual(t, sql, "SELECT CASE WHEN [field1] IN (1, 2) THEN 1 ELSE 0 END AS c, CASE WHEN [field2] BETWEEN 1 AND 2 THEN 1 ELSE 0 END,"+
+		" CASE WHEN 1 = 1 THEN 1 ELSE 0 END FROM [table1]")
+
+	// the constants and the fields are rendered as conditions
+	sql, _ = db.Select(field1).From(Table1).Where(False()).GetSQL()
+	assertEqual(t, sql, "SELECT [field1] FROM [table1] WHERE 1 = 0")
+	sql, _ = db.Select(field1).From(Table1).Where(field1.In()).GetSQL()
+	assertEqual(t, sql, "SELECT [field1] FROM [table1] WHERE 1 = 0")
+	sql, _ = db.Select(field1).From(Table1).Where(field2.Equals(1).Or(False())).GetSQL()
+	assertEqual(t, sql, "SELECT [field1] FROM [table1] WHERE [field2] = 1 OR 1 = 0")
+	sql, _ = db.Select(field1).From(Table1).Where(NewBooleanField(Table1, "field2")).GetSQL()
+	assertEqual(t, sql, "SELECT [field1] FROM [table1] WHERE [field2] <> 0")
+
+	sql, _ = db.Update(Table1).Set(field1, 1).Where(field2.Equals(2)).Limit(5).GetSQL()
+	assertEqual(t, sql, "UPDATE TOP (5) [table1] SET [field1] = 1 WHERE [field2] = 2")
+	sql, _ = db.DeleteFrom(Table1).Where(field2.Equals(2)).Limit(5).GetSQL()
+	assertEqual(t, sql, "DELETE TOP (5) FROM [table1] WHERE [field2] = 2")
+	sql, _ = db.DeleteFrom(Table1).Where(field2.Equals(2)).GetSQL()
+	assertEqual(t, sql, "DELETE FROM [table1] WHERE [field2] = 2")
+
+	// the ordered rows are chosen by a common table expression
+	sql, _ = db.Update(Table1).Set(field1, 1).Where(field2.Equals(2)).OrderBy(field1.Desc()).Limit(5).GetSQL()
+	assertEqual(t, sql, "WITH [sqlingo_target] AS (SELECT TOP (5) * FROM [table1] WHERE [field2] = 2 ORDER BY [field1] DESC)"+
+		" UPDATE [sqlingo_target] SET [field1] = 1")
+	sql, _ = db.DeleteFrom(Table1).Where(field2.Equals(2)).OrderBy(field1).Limit(5).GetSQL()
+	assertEqual(t, sql, "WITH [sqlingo_target] AS (SELECT TOP (5) * FROM [table1] WHERE [field2] = 2 ORDER BY [field1])"+
+		" DELETE FROM [sqlingo_target]")
+	if _, err := db.Update(Table1).Set(field1, 1).Where(field2.Equals(2)).OrderBy(field1).GetSQL(); err == nil {
+		t.Error("should get error here")
+	}
+	if _, err := db.DeleteFrom(Table1).Where(field2.Equals(2)).OrderBy(field1).GetSQL(); err == nil {
+		t.Error("should get error here")
+	}
+
+	// the lock hint only follows the tables
+	derivedTable := db.Select(field1).From(Table1).(selectStatus).asDerivedTable("t")
+	sql, _ = db.Select(field1).From(derivedTable).Join(table2).On(field1.Equals(field3)).ForUpdate().GetSQL()
+	assertEqual(t, sql, "SELECT [table1].[field1] FROM (SELECT [field1] FROM [table1]) AS t"+
+		" JOIN [table2] WITH (UPDLOCK, ROWLOCK) ON [table1].[field1] = [table2].[field3]")
+
+	mockDB := newMockDatabase()
+	mockDB.(*database).dialect = dialectMSSQL
+	_, _ = mockDB.SelectFrom(Table1).Where(field1.Equals(1)).Exists()
+	assertLastSql(t, "SELECT CASE WHEN EXISTS (SELECT <fields sql> FROM [table1] WHERE [field1] = 1) THEN 1 ELSE 0 END")
+}
@@ -1,6 +1,7 @@
 package sqlingo
 
 import (
+	"database/sql/driver"
 	"fmt"
 	"reflect"
 	"strconv"
@@ -165,6 +166,14 @@ type scope struct {
 	Database *database
 	Tables   []Table
 	lastJoin *join
+	args     *argList
+}
+
+func (s scope) getDialect() Dialect {
+	if s.Database == nil {
+		return dialectUnknown
+	}
+	return s.Database.dialect
 }
 
 func staticExpression(sql string, priority priority, isBool bool) expression {
@@ -177,7 +186,9 @@ func staticExpression(sql string, priority priority, isBool bool) expression {
 
 func True() BooleanExpression {
 	return expression{
-		sql:    "TRUE",
+		builder: func(scope scope) (string, error) {
+			return scope.getDialect().BooleanConstant(true), nil
+		},
 		isTrue: true,
 		isBool: true,
 	}
@@ -185,12 +196,29 @@ func True() BooleanExpression {
 
 func False() BooleanExpression {
 	return expression{
-		sql:     "FALSE",
+		builder: func(scope scope) (string, error) {
+			return scope.getDialect().BooleanConstant(false), nil
+		},
 		isFalse: true,
 		isBool:  true,
 	}
 }
 
+// asCondition converts a field used as a condition by the dialect, as a field is a value rather than a condition in SQL Server.
+func asCondition(e Expression) Expression {
+	field, ok := e.(actualField)
+	if !ok {
+		return e
+	}
+	return expression{builder: func(scope scope) (string, error) {
+		fieldSql, err := field.GetSQL(scope)
+		if err != nil {
+			return "", err
+		}
+		return scope.getDialect().Condition(fieldSql), nil
+	}, priority: 11, isBool: true}
+}
+
 // Raw create a raw SQL statement
 func Raw(sql string) UnknownExpression {
 	return expression{
@@ -237,6 +265,9 @@ func (e expression) As(name string) Alias {
 		if err != nil {
 			return "", err
 		}
+		if e.isBool {
+			expressionSql = scope.getDialect().BooleanValue(expressionSql)
+		}
 		return expressionSql + " AS " + name, nil
 	}}
 }
@@ -306,20 +337,6 @@ var needsEscape = [256]int{
 	0x1a: 1,
 }
 
-func quoteIdentifier(identifier string) (result dialectArray) {
-	for dialect := dialect(0); dialect < dialectCount; dialect++ {
-		switch dialect {
-		case dialectMySQL:
-			result[dialect] = "`" + identifier + "`"
-		case dialectMSSQL:
-			result[dialect] = "[" + identifier + "]"
-		default:
-			result[dialect] = "\"" + identifier + "\""
-		}
-	}
-	return
-}
-
 func quoteString(s string) string {
 	if s == "" {
 		return "''"
@@ -341,24 +358,36 @@ func quoteString(s string) string {
 	return *(*string)(unsafe.Pointer(&buf))
 }
 
+// quoteStandardString quotes a string in standard SQL, where only the single quote is escaped by doubling it.
+func quoteStandardString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
 func getSQL(scope scope, value interface{}) (sql string, priority priority, err error) {
-	const mysqlTimeFormat = "2006-01-02 15:04:05.000000"
 	if value == nil {
 		sql = "NULL"
 		return
 	}
 	switch value.(type) {
 	case int:
-		sql = strconv.Itoa(value.(int))
+		if scope.args != nil {
+			sql = scope.args.add(value)
+		} else {
+			sql = strconv.Itoa(value.(int))
+		}
 	case string:
-		sql = quoteString(value.(string))
+		if scope.args != nil {
+			sql = scope.args.add(value)
+		} else {
+			sql = scope.getDialect().QuoteString(value.(string))
+		}
 	case Expression:
 		sql, err = value.(Expression).GetSQL(scope)
 		priority = value.(Expression).getOperatorPriority()
 	case Assignment:
 		sql, err = value.(Assignment).GetSQL(scope)
 	case toSelectFinal:
-		sql, err = value.(toSelectFinal).GetSQL()
+		sql, err = value.(toSelectFinal).buildSQL(scope.args)
 		if err != nil {
 			return
 		}
@@ -373,17 +402,19 @@ func getSQL(scope scope, value interface{}) (sql string, priority priority, err
 		tm := value.(time.Time)
 		if tm.IsZero() {
 			sql = "NULL"
+		} else if scope.args != nil {
+			sql = scope.args.add(tm)
 		} else {
-			tmStr := tm.Format(mysqlTimeFormat)
-			sql = quoteString(tmStr)
+			sql = scope.getDialect().FormatTime(tm)
 		}
 	case *time.Time:
 		tm := value.(*time.Time)
 		if tm == nil || tm.IsZero() {
 			sql = "NULL"
+		} else if scope.args != nil {
+			sql = scope.args.add(*tm)
 		} else {
-			tmStr := tm.Format(mysqlTimeFormat)
-			sql = quoteString(tmStr)
+			sql = scope.getDialect().FormatTime(*tm)
 		}
 	default:
 		v := reflect.ValueOf(value)
@@ -409,13 +440,16 @@ func getSQLFromReflectValue(scope scope, v reflect.Value) (sql string, priority
 		return
 	}
 
+	if scope.args != nil {
+		if arg, ok := getArgFromReflectValue(v); ok {
+			sql = scope.args.add(arg)
+			return
+		}
+	}
+
 	switch v.Kind() {
 	case reflect.Bool:
-		if v.Bool() {
-			sql = "1"
-		} else {
-			sql = "0"
-		}
+		sql = scope.getDialect().FormatBool(v.Bool())
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		sql = strconv.FormatInt(v.Int(), 10)
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
@@ -423,8 +457,12 @@ func getSQLFromReflectValue(scope scope, v reflect.Value) (sql string, priority
 	case reflect.Float32, reflect.Float64:
 		sql = strconv.FormatFloat(v.Float(), 'g', -1, 64)
 	case reflect.String:
-		sql = quoteString(v.String())
+		sql = scope.getDialect().QuoteString(v.String())
 	case reflect.Array, reflect.Slice:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			sql = scope.getDialect().FormatBytes(v.Bytes())
+			return
+		}
 		length := v.Len()
 		values := make([]interface{}, length)
 		for i := 0; i < length; i++ {
@@ -436,7 +474,7 @@ func getSQLFromReflectValue(scope scope, v reflect.Value) (sql string, priority
 		}
 	default:
 		if vs, ok := v.Interface().(interface{ String() string }); ok {
-			sql = quoteString(vs.String())
+			sql = scope.getDialect().QuoteString(vs.String())
 		} else {
 			err = fmt.Errorf("invalid type %s", v.Kind().String())
 		}
@@ -444,6 +482,30 @@ func getSQLFromReflectValue(scope scope, v reflect.Value) (sql string, priority
 	return
 }
 
+// getArgFromReflectValue converts a value to a type accepted by database/sql as a bound argument.
+func getArgFromReflectValue(v reflect.Value) (arg interface{}, ok bool) {
+	if valuer, isValuer := v.Interface().(driver.Valuer); isValuer {
+		return valuer, true
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		return v.Bool(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Uint(), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	case reflect.String:
+		return v.String(), true
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Bytes(), true
+		}
+	}
+	return nil, false
+}
+
 /*
 1 INTERVAL
 2 BINARY, COLLATE
@@ -581,26 +643,18 @@ func (e expression) Concat(other interface{}) StringExpression {
 
 func (e expression) Contains(substring string) BooleanExpression {
 	return expression{builder: func(scope scope) (string, error) {
-		exprSQL, err := e.GetSQL(scope)
+		// arguments are passed in textual order so that "?" placeholders line up
+		name, substringFirst := scope.getDialect().StringPosition()
+		position := function(name, e, substring)
+		if substringFirst {
+			position = function(name, substring, e)
+		}
+		positionSql, err := position.GetSQL(scope)
 		if err != nil {
 			return "", err
 		}
-		substringSQL := quoteString(substring)
-
-		dialect := dialectUnknown
-		if scope.Database != nil {
-			dialect = scope.Database.dialect
-		}
-
-		switch dialect {
-		case dialectPostgres:
-			return "STRPOS(" + exprSQL + ", " + substringSQL + ") > 0", nil
-		case dialectSqlite3:
-			return "INSTR(" + exprSQL + ", " + substringSQL + ") > 0", nil
-		default:
-			return "LOCATE(" + substringSQL + ", " + exprSQL + ") > 0", nil
-		}
-	}, priority: 11}
+		return positionSql + " > 0", nil
+	}, priority: 11, isBool: true}
 }
 
 func (e expression) binaryOperation(operator string, value interface{}, priority priority, isBool bool) expression {
@@ -740,7 +794,7 @@ func (e expression) In(values ...interface{}) BooleanExpression {
 	}
 	joiner := func(exprSql, valuesSql string) string { return exprSql + " IN (" + valuesSql + ")" }
 	builder := e.getBuilder(e.Equals, joiner, values...)
-	return expression{builder: builder, priority: 11}
+	return expression{builder: builder, priority: 11, isBool: true}
 }
 
 func (e expression) NotIn(values ...interface{}) BooleanExpression {
@@ -750,7 +804,7 @@ func (e expression) NotIn(values ...interface{}) BooleanExpression {
 	}
 	joiner := func(exprSql, valuesSql string) string { return exprSql + " NOT IN (" + valuesSql + ")" }
 	builder := e.getBuilder(e.NotEquals, joiner, values...)
-	return expression{builder: builder, priority: 11}
+	return expression{builder: builder, priority: 11, isBool: true}
 }
 
 type joinerFunc = func(exprSql, valuesSql string) string
@@ -766,7 +820,7 @@ func (e expression) getBuilder(single booleanFunc, joiner joinerFunc, values ...
 			value := values[0]
 			if selectStatus, ok := value.(toSelectFinal); ok {
 				// IN subquery
-				valuesSql, err = selectStatus.GetSQL()
+				valuesSql, err = selectStatus.buildSQL(scope.args)
 				if err != nil {
 					return "", err
 				}
@@ -813,7 +867,7 @@ func (e expression) buildBetween(operator string, min interface{}, max interface
 			return "", err
 		}
 		return exprSql + operator + minSql + " AND " + maxSql, nil
-	}, priority: 12}
+	}, priority: 12, isBool: true}
 }
 
 func (e expression) getOperatorPriority() priority {
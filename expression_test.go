@@ -3,6 +3,7 @@ package sqlingo
 import (
 	"errors"
 	"testing"
+	"time"
 )
 
 type CustomInt int
@@ -171,6 +172,71 @@ func TestFunc(t *testing.T) {
 
 }
 
+func TestDialectLiterals(t *testing.T) {
+	tm := time.Date(2023, 9, 6, 18, 37, 46, 828000000, time.FixedZone("", 8*3600))
+	e := expression{sql: "<>"}
+	dialectToCases := map[Dialect][]struct {
+		value interface{}
+		sql   string
+	}{
+		dialectMySQL: {
+			{"abc", "'abc'"},
+			{`a'b\c`, `'a\'b\\c'`},
+			{CustomString("it's"), `'it\'s'`},
+			{[]byte{0xde, 0xad}, "X'dead'"},
+			{[]byte{}, "X''"},
+			{WellKnownBinary{1}, "X'01'"},
+			{true, "1"},
+			{CustomBool(false), "0"},
+			{tm, "'2023-09-06 18:37:46.828000'"},
+			{e.Contains("x"), "LOCATE('x', <>) > 0"},
+		},
+		dialectPostgres: {
+			{"abc", "'abc'"},
+			{`a'b\c`, `'a''b\c'`},
+			{CustomString("it's"), "'it''s'"},
+			{[]byte{0xde, 0xad}, `'\xdead'::bytea`},
+			{[]byte{}, `'\x'::bytea`},
+			{WellKnownBinary{1}, `'\x01'::bytea`},
+			{true, "TRUE"},
+			{CustomBool(false), "FALSE"},
+			{tm, "'2023-09-06 18:37:46.828000+08:00'"},
+			{e.Contains("x"), "STRPOS(<>, 'x') > 0"},
+		},
+		dialectSqlite3: {
+			{"abc", "'abc'"},
+			{`a'b\c`, `'a''b\c'`},
+			{CustomString("it's"), "'it''s'"},
+			{[]byte{0xde, 0xad}, "X'dead'"},
+			{[]byte{}, "X''"},
+			{WellKnownBinary{1}, "X'01'"},
+			{true, "1"},
+			{CustomBool(false), "0"},
+			{tm, "'2023-09-06 18:37:46.828000'"},
+			{e.Contains("x"), "INSTR(<>, 'x') > 0"},
+		},
+		dialectMSSQL: {
+			{"abc", "N'abc'"},
+			{`a'b\c`, `N'a''b\c'`},
+			{CustomString("it's"), "N'it''s'"},
+			{[]byte{0xde, 0xad}, "0xdead"},
+			{[]byte{}, "0x"},
+			{WellKnownBinary{1}, "0x01"},
+			{true, "1"},
+			{CustomBool(false), "0"},
+			{tm, "'2023-09-06T18:37:46.828'"},
+		},
+	}
+	for dialect, cases := range dialectToCases {
+		scope := scope{Database: &database{dialect: dialect}}
+		for _, c := range cases {
+			if sql, _, err := getSQL(scope, c.value); err != nil || sql != c.sql {
+				t.Errorf("dialect %s value [%v] generated [%s] expected [%s] (%v)", dialect.Name(), c.value, sql, c.sql, err)
+			}
+		}
+	}
+}
+
 func TestMisc(t *testing.T) {
 	assertValue(t, True(), "TRUE")
 	assertValue(t, False(), "FALSE")
@@ -43,6 +43,11 @@ type actualField struct {
 	table Table
 }
 
+// If uses the field as the condition, which is converted by the dialect.
+func (f actualField) If(trueValue interface{}, falseValue interface{}) UnknownExpression {
+	return If(f, trueValue, falseValue)
+}
+
 func (f actualField) GetTable() Table {
 	return f.table
 }
@@ -53,21 +58,25 @@ func newField(table Table, fieldName string) actualField {
 	fieldNameSqlArray := quoteIdentifier(fieldName)
 
 	var fullFieldNameSqlArray dialectArray
-	for dialect := dialect(0); dialect < dialectCount; dialect++ {
-		fullFieldNameSqlArray[dialect] = tableNameSqlArray[dialect] + "." + fieldNameSqlArray[dialect]
+	for i := range fullFieldNameSqlArray {
+		fullFieldNameSqlArray[i] = tableNameSqlArray[i] + "." + fieldNameSqlArray[i]
 	}
 
 	return actualField{
 		expression: expression{
 			builder: func(scope scope) (string, error) {
-				dialect := dialectUnknown
-				if scope.Database != nil {
-					dialect = scope.Database.dialect
+				dialect := scope.getDialect()
+				isFullName := len(scope.Tables) != 1 || scope.lastJoin != nil || scope.Tables[0].GetName() != tableName
+				if index := getBuiltinDialectIndex(dialect); index >= 0 {
+					if isFullName {
+						return fullFieldNameSqlArray[index], nil
+					}
+					return fieldNameSqlArray[index], nil
 				}
-				if len(scope.Tables) != 1 || scope.lastJoin != nil || scope.Tables[0].GetName() != tableName {
-					return fullFieldNameSqlArray[dialect], nil
+				if isFullName {
+					return dialect.QuoteIdentifier(tableName) + "." + dialect.QuoteIdentifier(fieldName), nil
 				}
-				return fieldNameSqlArray[dialect], nil
+				return dialect.QuoteIdentifier(fieldName), nil
 			},
 		},
 		table: table,
@@ -116,11 +125,20 @@ func (fields fieldList) GetSQL(scope scope) (string, error) {
 			}
 		}
 	} else {
-		fieldsSql, err := commaFields(scope, fields)
-		if err != nil {
-			return "", err
+		for i, field := range fields {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			fieldSql, err := field.GetSQL(scope)
+			if err != nil {
+				return "", err
+			}
+			// the conditions are selected as values
+			if e, ok := field.(expression); ok && e.isBool {
+				fieldSql = scope.getDialect().BooleanValue(fieldSql)
+			}
+			sb.WriteString(fieldSql)
 		}
-		sb.WriteString(fieldsSql)
 	}
 	return sb.String(), nil
 }
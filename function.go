@@ -6,7 +6,7 @@ func function(name string, args ...interface{}) expression {
 		if err != nil {
 			return "", err
 		}
-		return name + "(" + valuesSql + ")", nil
+		return scope.getDialect().FunctionName(name) + "(" + valuesSql + ")", nil
 	}}
 }
 
@@ -27,7 +27,7 @@ func Count(arg interface{}) NumberExpression {
 
 // If creates an expression of IF function.
 func If(predicate Expression, trueValue interface{}, falseValue interface{}) (result UnknownExpression) {
-	return function("IF", predicate, trueValue, falseValue)
+	return function("IF", asCondition(predicate), trueValue, falseValue)
 }
 
 // Length creates an expression of LENGTH function.
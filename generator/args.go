@@ -10,12 +10,13 @@ type options struct {
 	dataSourceName string
 	tableNames     []string
 	forceCases     []string
+	versionColumn  string
 }
 
 func printUsageAndExit(exampleDataSourceName string) {
 	cmd := os.Args[0]
 	_, _ = fmt.Fprintf(os.Stderr, `Usage:
-	%s [-t table1,table2,...] [-forcecases ID,IDs,HTML] dataSourceName
+	%s [-t table1,table2,...] [-forcecases ID,IDs,HTML] [-version version_column] dataSourceName
 Example:
 	%s "%s"
 `, cmd, cmd, exampleDataSourceName)
@@ -26,6 +27,7 @@ func parseArgs(exampleDataSourceName string) (options options) {
 	var args []string
 	parseTable := false
 	parseForceCases := false
+	parseVersion := false
 	for _, arg := range os.Args[1:] {
 		if arg != "" && arg[0] == '-' {
 			switch arg[1:] {
@@ -39,6 +41,11 @@ func parseArgs(exampleDataSourceName string) (options options) {
 					printUsageAndExit(exampleDataSourceName)
 				}
 				parseForceCases = true
+			case "version":
+				if parseVersion {
+					printUsageAndExit(exampleDataSourceName)
+				}
+				parseVersion = true
 			case "timeAsString":
 				timeAsString = true
 			default:
@@ -51,12 +58,15 @@ func parseArgs(exampleDataSourceName string) (options options) {
 			} else if parseForceCases {
 				options.forceCases = append(options.forceCases, strings.Split(arg, ",")...)
 				parseForceCases = false
+			} else if parseVersion {
+				options.versionColumn = arg
+				parseVersion = false
 			} else {
 				args = append(args, arg)
 			}
 		}
 	}
-	if parseTable || parseForceCases {
+	if parseTable || parseForceCases || parseVersion {
 		// "-t" not closed
 		printUsageAndExit(exampleDataSourceName)
 	}
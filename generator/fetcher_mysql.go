@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"regexp"
 	"strconv"
+	"strings"
 )
 
 var timeAsString = false
@@ -77,6 +78,8 @@ func (m mysqlSchemaFetcher) GetFieldDescriptors(tableName string) ([]fieldDescri
 			}
 		}
 		unsigned := submatches[5] == "unsigned"
+		// the default value is NULL if not present
+		_, hasDefault := row["Default"]
 
 		result = append(result, fieldDescriptor{
 			Name:      row["Field"],
@@ -85,6 +88,10 @@ func (m mysqlSchemaFetcher) GetFieldDescriptors(tableName string) ([]fieldDescri
 			Unsigned:  unsigned,
 			AllowNull: row["Null"] == "YES",
 			Comment:   row["Comment"],
+
+			PrimaryKey:    row["Key"] == "PRI",
+			AutoIncrement: row["Key"] == "PRI" && strings.Contains(row["Extra"], "auto_increment"),
+			HasDefault:    hasDefault,
 		})
 	}
 	return result, nil
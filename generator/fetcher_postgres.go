@@ -1,6 +1,9 @@
 package generator
 
-import "database/sql"
+import (
+	"database/sql"
+	"strings"
+)
 
 type postgresSchemaFetcher struct {
 	db *sql.DB
@@ -29,18 +32,25 @@ func (p postgresSchemaFetcher) GetTableNames() (tableNames []string, err error)
 }
 
 func (p postgresSchemaFetcher) GetFieldDescriptors(tableName string) (result []fieldDescriptor, err error) {
-	rows, err := p.db.Query("SELECT column_name, is_nullable, data_type FROM information_schema.columns WHERE table_schema = 'public' AND table_name = $1", tableName)
+	rows, err := p.db.Query("SELECT c.column_name, c.is_nullable, c.data_type, COALESCE(c.column_default, ''), c.is_identity,"+
+		" EXISTS (SELECT 1 FROM information_schema.table_constraints tc"+
+		" JOIN information_schema.key_column_usage kcu ON kcu.constraint_schema = tc.constraint_schema AND kcu.constraint_name = tc.constraint_name"+
+		" WHERE tc.constraint_type = 'PRIMARY KEY' AND tc.table_schema = c.table_schema AND tc.table_name = c.table_name AND kcu.column_name = c.column_name)"+
+		" FROM information_schema.columns c WHERE c.table_schema = 'public' AND c.table_name = $1", tableName)
 	if err != nil {
 		return
 	}
 	defer rows.Close()
 	for rows.Next() {
 		var fieldDescriptor fieldDescriptor
-		var isNullable string
-		if err = rows.Scan(&fieldDescriptor.Name, &isNullable, &fieldDescriptor.Type); err != nil {
+		var isNullable, columnDefault, isIdentity string
+		if err = rows.Scan(&fieldDescriptor.Name, &isNullable, &fieldDescriptor.Type, &columnDefault, &isIdentity, &fieldDescriptor.PrimaryKey); err != nil {
 			return
 		}
 		fieldDescriptor.AllowNull = isNullable == "YES"
+		// serial columns are defaulted to nextval() of their sequences
+		fieldDescriptor.AutoIncrement = fieldDescriptor.PrimaryKey && (isIdentity == "YES" || strings.HasPrefix(columnDefault, "nextval("))
+		fieldDescriptor.HasDefault = columnDefault != "" || isIdentity == "YES"
 		result = append(result, fieldDescriptor)
 	}
 	return
@@ -1,6 +1,9 @@
 package generator
 
-import "database/sql"
+import (
+	"database/sql"
+	"strings"
+)
 
 type sqlite3SchemaFetcher struct {
 	db *sql.DB
@@ -28,20 +31,31 @@ func (s sqlite3SchemaFetcher) GetTableNames() (tableNames []string, err error) {
 }
 
 func (s sqlite3SchemaFetcher) GetFieldDescriptors(tableName string) (result []fieldDescriptor, err error) {
-	rows, err := s.db.Query("SELECT `name`, `type`, `notnull` FROM pragma_table_info('" + tableName + "')")
+	rows, err := s.db.Query("SELECT `name`, `type`, `notnull`, `pk`, `dflt_value` IS NOT NULL FROM pragma_table_info('" + tableName + "')")
 	if err != nil {
 		return
 	}
 	defer rows.Close()
+	primaryKeyIndex := -1
+	primaryKeyCount := 0
 	for rows.Next() {
 		var fieldDescriptor fieldDescriptor
-		var notNull int
-		if err = rows.Scan(&fieldDescriptor.Name, &fieldDescriptor.Type, &notNull); err != nil {
+		var notNull, pk int
+		if err = rows.Scan(&fieldDescriptor.Name, &fieldDescriptor.Type, &notNull, &pk, &fieldDescriptor.HasDefault); err != nil {
 			return
 		}
 		fieldDescriptor.AllowNull = notNull == 0
+		fieldDescriptor.PrimaryKey = pk > 0
+		if pk > 0 {
+			primaryKeyIndex = len(result)
+			primaryKeyCount++
+		}
 		result = append(result, fieldDescriptor)
 	}
+	// only a single INTEGER PRIMARY KEY column is an alias of rowid, which is generated automatically
+	if primaryKeyCount == 1 && strings.EqualFold(result[primaryKeyIndex].Type, "integer") {
+		result[primaryKeyIndex].AutoIncrement = true
+	}
 	return
 }
 
@@ -32,6 +32,12 @@ type fieldDescriptor struct {
 	Unsigned  bool
 	AllowNull bool
 	Comment   string
+	// PrimaryKey is true if the field is (a part of) the primary key.
+	PrimaryKey bool
+	// AutoIncrement is true for the auto-increment primary key.
+	AutoIncrement bool
+	// HasDefault is true if the field has a default value.
+	HasDefault bool
 }
 
 func convertToExportedIdentifier(s string, forceCases []string) string {
@@ -270,7 +276,7 @@ func Generate(driverName string, exampleDataSourceName string) (string, error) {
 		tableCodeMap[tableName] = item
 		go func(tableName string) {
 			defer wg.Done()
-			tableCode, err := generateTable(schemaFetcher, tableName, options.forceCases)
+			tableCode, err := generateTable(schemaFetcher, tableName, options.forceCases, options.versionColumn)
 			if err != nil {
 				item.err = err
 				return
@@ -316,7 +322,7 @@ func generateGetTable(options options) string {
 	return code
 }
 
-func generateTable(schemaFetcher schemaFetcher, tableName string, forceCases []string) (string, error) {
+func generateTable(schemaFetcher schemaFetcher, tableName string, forceCases []string, versionColumn string) (string, error) {
 	fieldDescriptors, err := schemaFetcher.GetFieldDescriptors(tableName)
 	if err != nil {
 		return "", err
@@ -338,6 +344,11 @@ func generateTable(schemaFetcher schemaFetcher, tableName string, forceCases []s
 	fieldsSQL := ""
 	fullFieldsSQL := ""
 	values := ""
+	autoIncrementCode := ""
+	autoIncrementCount := 0
+	defaultFields := ""
+	primaryKeyFields := ""
+	versionField := ""
 
 	for _, fieldDescriptor := range fieldDescriptors {
 
@@ -386,6 +397,30 @@ func generateTable(schemaFetcher schemaFetcher, tableName string, forceCases []s
 		fullFieldsSQL += schemaFetcher.QuoteIdentifier(tableName) + "." + schemaFetcher.QuoteIdentifier(fieldDescriptor.Name)
 
 		values += "m." + goName + ", "
+
+		// the version column for optimistic locking should be a non-null integer
+		if versionColumn != "" && fieldDescriptor.Name == versionColumn && isIntegerType(goType) && !strings.HasPrefix(goType, "*") {
+			versionField = goName
+		}
+
+		if fieldDescriptor.PrimaryKey {
+			primaryKeyFields += "t." + goName + ", "
+		}
+
+		if fieldDescriptor.HasDefault || fieldDescriptor.AutoIncrement {
+			defaultFields += "t." + goName + ", "
+		}
+
+		if fieldDescriptor.AutoIncrement {
+			autoIncrementCount++
+			if isIntegerType(goType) {
+				autoIncrementCode = generateAutoIncrement(className, goName, goType)
+			}
+		}
+	}
+	if autoIncrementCount != 1 {
+		// the ids of a composite primary key could not be generated
+		autoIncrementCode = ""
 	}
 	code := ""
 	code += "type " + tableStructName + " struct {\n\ttable\n\n"
@@ -410,6 +445,24 @@ func generateTable(schemaFetcher schemaFetcher, tableName string, forceCases []s
 	code += "\t}\n"
 	code += "}\n\n"
 
+	if primaryKeyFields != "" {
+		code += "func (t t" + className + ") GetPrimaryKeyFields() []sqlingo.Field {\n"
+		code += "\treturn []sqlingo.Field{" + primaryKeyFields + "}\n"
+		code += "}\n\n"
+	}
+
+	if versionField != "" {
+		code += "func (t t" + className + ") GetVersionField() sqlingo.Field {\n"
+		code += "\treturn t." + versionField + "\n"
+		code += "}\n\n"
+	}
+
+	if defaultFields != "" {
+		code += "func (t t" + className + ") GetDefaultFields() []sqlingo.Field {\n"
+		code += "\treturn []sqlingo.Field{" + defaultFields + "}\n"
+		code += "}\n\n"
+	}
+
 	code += "func (t t" + className + ") GetFieldsSQL() string {\n"
 	code += "\treturn " + strconv.Quote(fieldsSQL) + "\n"
 	code += "}\n\n"
@@ -429,9 +482,47 @@ func generateTable(schemaFetcher schemaFetcher, tableName string, forceCases []s
 	code += "func (m " + modelClassName + ") GetValues() []interface{} {\n"
 	code += "\treturn []interface{}{" + values + "}\n"
 	code += "}\n\n"
+
+	code += autoIncrementCode
 	return code, nil
 }
 
+func isIntegerType(goType string) bool {
+	goType = strings.TrimPrefix(goType, "*")
+	return strings.HasPrefix(goType, "int") || strings.HasPrefix(goType, "uint")
+}
+
+func generateAutoIncrement(className string, goName string, goType string) string {
+	modelClassName := className + "Model"
+
+	code := "func (t t" + className + ") GetAutoIncrementField() sqlingo.Field {\n"
+	code += "\treturn t." + goName + "\n"
+	code += "}\n\n"
+
+	if strings.HasPrefix(goType, "*") {
+		// the nullable id is nil before inserting
+		code += "func (m " + modelClassName + ") GetAutoIncrementID() int64 {\n"
+		code += "\tif m." + goName + " == nil { return 0 }\n"
+		code += "\treturn int64(*m." + goName + ")\n"
+		code += "}\n\n"
+
+		code += "func (m *" + modelClassName + ") SetAutoIncrementID(id int64) {\n"
+		code += "\tvalue := " + goType[1:] + "(id)\n"
+		code += "\tm." + goName + " = &value\n"
+		code += "}\n\n"
+		return code
+	}
+
+	code += "func (m " + modelClassName + ") GetAutoIncrementID() int64 {\n"
+	code += "\treturn int64(m." + goName + ")\n"
+	code += "}\n\n"
+
+	code += "func (m *" + modelClassName + ") SetAutoIncrementID(id int64) {\n"
+	code += "\tm." + goName + " = " + goType + "(id)\n"
+	code += "}\n\n"
+	return code
+}
+
 // replaceTypeSpace : To compatible some types contains spaces in postgresql
 // like [character varying, timestamp without time zone, timestamp with time zone]
 func replaceTypeSpace(typename string) string {
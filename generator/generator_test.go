@@ -1,6 +1,9 @@
 package generator
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestConvert(t *testing.T) {
 	m := map[string]string{
@@ -26,3 +29,137 @@ func TestConvert(t *testing.T) {
 		}
 	}
 }
+
+type mockSchemaFetcher struct {
+	fieldDescriptors []fieldDescriptor
+}
+
+func (m mockSchemaFetcher) GetDatabaseName() (string, error) {
+	return "test", nil
+}
+
+func (m mockSchemaFetcher) GetTableNames() ([]string, error) {
+	return []string{"test"}, nil
+}
+
+func (m mockSchemaFetcher) GetFieldDescriptors(tableName string) ([]fieldDescriptor, error) {
+	return m.fieldDescriptors, nil
+}
+
+func (m mockSchemaFetcher) QuoteIdentifier(identifier string) string {
+	return "`" + identifier + "`"
+}
+
+func TestGenerateDefaultFields(t *testing.T) {
+	code, err := generateTable(mockSchemaFetcher{fieldDescriptors: []fieldDescriptor{
+		{Name: "id", Type: "bigint", AutoIncrement: true},
+		{Name: "name", Type: "varchar", Size: 32},
+		{Name: "created_at", Type: "datetime", HasDefault: true},
+	}}, "test", nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(code, "func (t tTest) GetDefaultFields() []sqlingo.Field {\n\treturn []sqlingo.Field{t.Id, t.CreatedAt, }\n}") {
+		t.Error(code)
+	}
+
+	code, err = generateTable(mockSchemaFetcher{fieldDescriptors: []fieldDescriptor{
+		{Name: "name", Type: "varchar", Size: 32},
+	}}, "test", nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(code, "GetDefaultFields") {
+		t.Error(code)
+	}
+}
+
+func TestGeneratePrimaryKeyFields(t *testing.T) {
+	code, err := generateTable(mockSchemaFetcher{fieldDescriptors: []fieldDescriptor{
+		{Name: "user_id", Type: "bigint", PrimaryKey: true},
+		{Name: "role_id", Type: "bigint", PrimaryKey: true},
+		{Name: "name", Type: "varchar", Size: 32},
+	}}, "test", nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(code, "func (t tTest) GetPrimaryKeyFields() []sqlingo.Field {\n\treturn []sqlingo.Field{t.UserId, t.RoleId, }\n}") {
+		t.Error(code)
+	}
+
+	code, err = generateTable(mockSchemaFetcher{fieldDescriptors: []fieldDescriptor{
+		{Name: "name", Type: "varchar", Size: 32},
+	}}, "test", nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(code, "GetPrimaryKeyFields") {
+		t.Error(code)
+	}
+}
+
+func TestGenerateVersionField(t *testing.T) {
+	fetcher := mockSchemaFetcher{fieldDescriptors: []fieldDescriptor{
+		{Name: "id", Type: "bigint", PrimaryKey: true},
+		{Name: "lock_version", Type: "int"},
+		{Name: "name", Type: "varchar", Size: 32},
+	}}
+	code, err := generateTable(fetcher, "test", nil, "lock_version")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(code, "func (t tTest) GetVersionField() sqlingo.Field {\n\treturn t.LockVersion\n}") {
+		t.Error(code)
+	}
+
+	for _, versionColumn := range []string{"", "version", "name"} {
+		code, err = generateTable(fetcher, "test", nil, versionColumn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(code, "GetVersionField") {
+			t.Error(versionColumn, code)
+		}
+	}
+}
+
+func TestGenerateAutoIncrement(t *testing.T) {
+	code, err := generateTable(mockSchemaFetcher{fieldDescriptors: []fieldDescriptor{
+		{Name: "id", Type: "int", Unsigned: true, AutoIncrement: true},
+		{Name: "name", Type: "varchar", Size: 32},
+	}}, "test", nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"func (t tTest) GetAutoIncrementField() sqlingo.Field {\n\treturn t.Id\n}",
+		"func (m TestModel) GetAutoIncrementID() int64 {\n\treturn int64(m.Id)\n}",
+		"func (m *TestModel) SetAutoIncrementID(id int64) {\n\tm.Id = uint32(id)\n}",
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("%s not found in generated code", expected)
+		}
+	}
+
+	code, err = generateTable(mockSchemaFetcher{fieldDescriptors: []fieldDescriptor{
+		{Name: "id", Type: "integer", AllowNull: true, AutoIncrement: true},
+	}}, "test", nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(code, "\tvalue := int64(id)\n\tm.Id = &value\n") {
+		t.Error(code)
+	}
+
+	// composite primary key
+	code, err = generateTable(mockSchemaFetcher{fieldDescriptors: []fieldDescriptor{
+		{Name: "a", Type: "int", AutoIncrement: true},
+		{Name: "b", Type: "int", AutoIncrement: true},
+	}}, "test", nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(code, "AutoIncrement") {
+		t.Error(code)
+	}
+}
@@ -14,20 +14,38 @@ type insertStatus struct {
 	fields                          []Field
 	values                          []interface{}
 	models                          []interface{}
+	omitFields                      []Field
+	omitDefaults                    bool
+	selectStatement                 toSelectFinal
+	conflictFields                  []Field
+	ignoreConflict                  bool
 	onDuplicateKeyUpdateAssignments []assignment
+	setAll                          bool
+	setAllIndex                     int
+	setAllExcept                    []Field
+	returning                       []Field
 	ctx                             context.Context
 }
 
 type insertWithTable interface {
-	Fields(fields ...Field) insertWithValues
+	Fields(fields ...Field) insertWithFields
 	Values(values ...interface{}) insertWithValues
 	Models(models ...interface{}) insertWithModels
+	Select(selectStatus toSelectFinal) insertWithSelect
+}
+
+type insertWithFields interface {
+	insertWithValues
+	Select(selectStatus toSelectFinal) insertWithSelect
 }
 
 type insertWithValues interface {
 	toInsertWithContext
 	toInsertFinal
+	toInsertReturning
+	toInsertBatch
 	Values(values ...interface{}) insertWithValues
+	OnConflict(fields ...Field) insertWithOnConflict
 	OnDuplicateKeyIgnore() toInsertWithDuplicateKey
 	OnDuplicateKeyUpdate() insertWithOnDuplicateKeyUpdateBegin
 }
@@ -35,14 +53,39 @@ type insertWithValues interface {
 type insertWithModels interface {
 	toInsertWithContext
 	toInsertFinal
+	toInsertReturning
+	toInsertBatch
 	Models(models ...interface{}) insertWithModels
+	Omit(fields ...Field) insertWithModels
+	OmitDefaults() insertWithModels
+	OnConflict(fields ...Field) insertWithOnConflict
+	OnDuplicateKeyIgnore() toInsertWithDuplicateKey
+	OnDuplicateKeyUpdate() insertWithOnDuplicateKeyUpdateBegin
+}
+
+type insertWithSelect interface {
+	toInsertWithContext
+	toInsertFinal
+	toInsertReturning
+	OnConflict(fields ...Field) insertWithOnConflict
 	OnDuplicateKeyIgnore() toInsertWithDuplicateKey
 	OnDuplicateKeyUpdate() insertWithOnDuplicateKeyUpdateBegin
 }
 
+// defaultFieldsTable is implemented by the generated table with fields having default values.
+type defaultFieldsTable interface {
+	GetDefaultFields() []Field
+}
+
+type insertWithOnConflict interface {
+	DoUpdate() insertWithOnDuplicateKeyUpdateBegin
+	DoNothing() toInsertWithDuplicateKey
+}
+
 type insertWithOnDuplicateKeyUpdateBegin interface {
 	Set(Field Field, value interface{}) insertWithOnDuplicateKeyUpdate
 	SetIf(condition bool, Field Field, value interface{}) insertWithOnDuplicateKeyUpdate
+	SetAllExcept(fields ...Field) insertWithOnDuplicateKeyUpdate
 }
 
 type insertWithOnDuplicateKeyUpdate interface {
@@ -56,12 +99,19 @@ type toInsertWithContext interface {
 
 type toInsertFinal interface {
 	GetSQL() (string, error)
+	GetSQLWithArgs() (string, []interface{}, error)
 	Execute() (result sql.Result, err error)
 }
 
 type toInsertWithDuplicateKey interface {
 	toInsertWithContext
 	toInsertFinal
+	toInsertReturning
+	toInsertBatch
+}
+
+type toInsertReturning interface {
+	Returning(fields ...Field) returningWithFields
 }
 
 func (d *database) InsertInto(table Table) insertWithTable {
@@ -72,7 +122,7 @@ func (d *database) ReplaceInto(table Table) insertWithTable {
 	return insertStatus{method: "REPLACE", scope: scope{Database: d, Tables: []Table{table}}}
 }
 
-func (s insertStatus) Fields(fields ...Field) insertWithValues {
+func (s insertStatus) Fields(fields ...Field) insertWithFields {
 	s.fields = fields
 	return s
 }
@@ -83,6 +133,12 @@ func (s insertStatus) Values(values ...interface{}) insertWithValues {
 	return s
 }
 
+// Select inserts the rows selected by the SELECT statement, as INSERT ... SELECT.
+func (s insertStatus) Select(selectStatus toSelectFinal) insertWithSelect {
+	s.selectStatement = selectStatus
+	return s
+}
+
 func addModel(models *[]Model, model interface{}) error {
 	if model, ok := model.(Model); ok {
 		*models = append(*models, model)
@@ -109,11 +165,44 @@ func addModel(models *[]Model, model interface{}) error {
 	}
 }
 
+// Models inserts the models. The auto-increment ids generated by the database are written back into the models
+// passed by pointer, by RETURNING in PostgreSQL and SQLite, or by LastInsertId in MySQL.
+// If the ids to be generated cannot be determined, such as in SQL Server or in an upsert of MySQL,
+// Execute returns an error without executing the statement; if they turn out to be undeterminable after it,
+// such as the affected rows not matching the models, the result is returned along with the error.
 func (s insertStatus) Models(models ...interface{}) insertWithModels {
 	s.models = models
 	return s
 }
 
+// OnConflict handles the rows conflicting on the given fields, which are ignored by MySQL and required by MSSQL.
+func (s insertStatus) OnConflict(fields ...Field) insertWithOnConflict {
+	s.conflictFields = fields
+	return s
+}
+
+func (s insertStatus) DoUpdate() insertWithOnDuplicateKeyUpdateBegin {
+	return s
+}
+
+func (s insertStatus) DoNothing() toInsertWithDuplicateKey {
+	s.ignoreConflict = true
+	return s
+}
+
+// Omit leaves the fields out of the inserted models.
+func (s insertStatus) Omit(fields ...Field) insertWithModels {
+	s.omitFields = append(append([]Field{}, s.omitFields...), fields...)
+	return s
+}
+
+// OmitDefaults leaves the fields with default values to the database if they are zero in the models.
+// A field is rendered as DEFAULT for the models with zero value, if it's not zero in the other models.
+func (s insertStatus) OmitDefaults() insertWithModels {
+	s.omitDefaults = true
+	return s
+}
+
 func (s insertStatus) OnDuplicateKeyUpdate() insertWithOnDuplicateKeyUpdateBegin {
 	return s
 }
@@ -134,29 +223,196 @@ func (s insertStatus) Set(field Field, value interface{}) insertWithOnDuplicateK
 	return s
 }
 
+// SetAllExcept updates every inserted field except the given ones to the value proposed for insertion.
+// The primary key and the auto-increment field are never updated, and the fields left out of the inserted models are not updated either.
+func (s insertStatus) SetAllExcept(fields ...Field) insertWithOnDuplicateKeyUpdate {
+	s.setAll = true
+	s.setAllIndex = len(s.onDuplicateKeyUpdateAssignments)
+	s.setAllExcept = append(append([]Field{}, s.setAllExcept...), fields...)
+	return s
+}
+
+// isUpsert tells whether the statement updates or ignores the conflicting rows.
+func (s insertStatus) isUpsert() bool {
+	return s.ignoreConflict || s.setAll || len(s.onDuplicateKeyUpdateAssignments) > 0
+}
+
+// getUpsertAssignments returns the assignments of the upsert, including the ones of SetAllExcept for the inserted fields.
+func (s insertStatus) getUpsertAssignments(insertedFields []Field) []assignment {
+	if !s.setAll {
+		return s.onDuplicateKeyUpdateAssignments
+	}
+	excluded := make(map[string]bool)
+	exclude := func(fields ...Field) {
+		for _, field := range fields {
+			if fieldSql, err := field.GetSQL(s.scope); err == nil {
+				excluded[fieldSql] = true
+			}
+		}
+	}
+	exclude(s.setAllExcept...)
+	table := s.scope.Tables[0]
+	if primaryKeyTable, ok := table.(primaryKeyTable); ok {
+		exclude(primaryKeyTable.GetPrimaryKeyFields()...)
+	}
+	if autoIncrementTable, ok := table.(autoIncrementTable); ok {
+		exclude(autoIncrementTable.GetAutoIncrementField())
+	}
+
+	assignments := append([]assignment{}, s.onDuplicateKeyUpdateAssignments[:s.setAllIndex]...)
+	for _, field := range insertedFields {
+		if fieldSql, err := field.GetSQL(s.scope); err == nil && excluded[fieldSql] {
+			continue
+		}
+		assignments = append(assignments, assignment{
+			field: field,
+			value: Inserted(field),
+		})
+	}
+	return append(assignments, s.onDuplicateKeyUpdateAssignments[s.setAllIndex:]...)
+}
+
+// Inserted refers to the value proposed for insertion of the field, in the assignments of an upsert.
+// It's rendered as VALUES(field) or `new`.field in MySQL, and EXCLUDED.field in PostgreSQL.
+func Inserted(field Field) UnknownExpression {
+	return expression{
+		builder: func(s scope) (string, error) {
+			if table := field.GetTable(); table != nil {
+				// refer to the field without table name
+				s = scope{Database: s.Database, Tables: []Table{table}}
+			}
+			fieldSql, err := field.GetSQL(s)
+			if err != nil {
+				return "", err
+			}
+			return s.getDialect().InsertedValue(fieldSql), nil
+		},
+	}
+}
+
 func (s insertStatus) OnDuplicateKeyIgnore() toInsertWithDuplicateKey {
-	firstField := s.scope.Tables[0].GetFields()[0]
-	return s.OnDuplicateKeyUpdate().Set(firstField, firstField)
+	return s.DoNothing()
+}
+
+// getModelFieldsAndValues returns the fields to insert and the values of each model,
+// leaving out the omitted fields and the fields to be generated by the database.
+func (s insertStatus) getModelFieldsAndValues(models []Model) ([]Field, []interface{}, error) {
+	table := models[0].GetTable()
+	allFields := table.GetFields()
+	rows := make([][]interface{}, len(models))
+	for i, model := range models {
+		if model.GetTable().GetName() != s.scope.Tables[0].GetName() {
+			return nil, nil, errors.New("invalid table from model")
+		}
+		rows[i] = model.GetValues()
+	}
+
+	omitted := make([]bool, len(allFields))
+	useDefault := make([]bool, len(allFields))
+
+	// leave the auto-increment field to the database if no model has its id
+	generating, err := isGeneratingAutoIncrementIDs(models)
+	if err != nil && !s.omitDefaults {
+		// with OmitDefaults, the missing ids are rendered as DEFAULT
+		return nil, nil, err
+	}
+	if generating {
+		if index := getAutoIncrementIndex(s.scope, table, allFields); index >= 0 {
+			omitted[index] = true
+		}
+	}
+
+	for _, field := range s.omitFields {
+		index := getFieldIndex(s.scope, allFields, field)
+		if index < 0 {
+			return nil, nil, errors.New("omitted field is not in the table")
+		}
+		omitted[index] = true
+	}
+
+	if defaultFieldsTable, ok := table.(defaultFieldsTable); ok && s.omitDefaults {
+		for _, field := range defaultFieldsTable.GetDefaultFields() {
+			index := getFieldIndex(s.scope, allFields, field)
+			if index < 0 || omitted[index] {
+				continue
+			}
+			zeroCount := 0
+			for _, row := range rows {
+				if isZeroValue(row[index]) {
+					zeroCount++
+				}
+			}
+			if zeroCount == len(rows) {
+				omitted[index] = true
+			} else if zeroCount > 0 {
+				useDefault[index] = true
+			}
+		}
+	}
+
+	var fields []Field
+	var defaultValue interface{}
+	for i, field := range allFields {
+		if !omitted[i] {
+			fields = append(fields, field)
+		}
+		if useDefault[i] && defaultValue == nil {
+			defaultValueSql, err := s.scope.getDialect().DefaultValue()
+			if err != nil {
+				return nil, nil, err
+			}
+			defaultValue = Raw(defaultValueSql)
+		}
+	}
+	values := make([]interface{}, len(rows))
+	for i, row := range rows {
+		rowValues := make([]interface{}, 0, len(fields))
+		for j, value := range row {
+			if omitted[j] {
+				continue
+			}
+			if useDefault[j] && isZeroValue(value) {
+				value = defaultValue
+			}
+			rowValues = append(rowValues, value)
+		}
+		values[i] = rowValues
+	}
+	return fields, values, nil
+}
+
+func isZeroValue(value interface{}) bool {
+	return value == nil || reflect.ValueOf(value).IsZero()
 }
 
 func (s insertStatus) GetSQL() (string, error) {
+	sql, _, err := s.GetSQLWithArgs()
+	return sql, err
+}
+
+func (s insertStatus) GetSQLWithArgs() (string, []interface{}, error) {
+	args := newArgList(s.scope.Database)
+	sql, err := s.buildSQL(args)
+	if err != nil {
+		return "", nil, err
+	}
+	return sql, args.getValues(), nil
+}
+
+func (s insertStatus) buildSQL(args *argList) (string, error) {
+	s.scope.args = args
 	var fields []Field
 	var values []interface{}
 	if len(s.models) > 0 {
-		models := make([]Model, 0, len(s.models))
-		for _, model := range s.models {
-			if err := addModel(&models, model); err != nil {
-				return "", err
-			}
+		models, err := getModels(s.models)
+		if err != nil {
+			return "", err
 		}
 
 		if len(models) > 0 {
-			fields = models[0].GetTable().GetFields()
-			for _, model := range models {
-				if model.GetTable().GetName() != s.scope.Tables[0].GetName() {
-					return "", errors.New("invalid table from model")
-				}
-				values = append(values, model.GetValues())
+			fields, values, err = s.getModelFieldsAndValues(models)
+			if err != nil {
+				return "", err
 			}
 		}
 	} else {
@@ -168,41 +424,88 @@ func (s insertStatus) GetSQL() (string, error) {
 		values = s.values
 	}
 
-	if len(values) == 0 {
+	if len(values) == 0 && s.selectStatement == nil {
 		return "/* INSERT without VALUES */ DO 0", nil
 	}
 
-	tableSql := s.scope.Tables[0].GetSQL(s.scope)
-	fieldsSql, err := commaFields(s.scope, fields)
+	fieldsSql, err := getFieldsSQL(s.scope, fields)
 	if err != nil {
 		return "", err
 	}
-	valuesSql, err := commaValues(s.scope, values)
+	statement := UpsertStatement{
+		Method: s.method,
+		Table:  s.scope.Tables[0].GetSQL(s.scope),
+		Fields: fieldsSql,
+	}
+	if s.selectStatement != nil {
+		if selected, ok := s.selectStatement.(selectStatus); ok {
+			if count, ok := selected.getFieldCount(); ok && count != len(fields) {
+				return "", fmt.Errorf("field count mismatch: %d fields to insert but %d fields selected", len(fields), count)
+			}
+		}
+		statement.Select, err = s.selectStatement.buildSQL(args)
+	} else {
+		statement.Values, err = commaValues(s.scope, values)
+	}
 	if err != nil {
 		return "", err
 	}
 
-	sqlString := s.method + " INTO " + tableSql + " (" + fieldsSql + ") VALUES " + valuesSql
-	if len(s.onDuplicateKeyUpdateAssignments) > 0 {
-		assignmentsSql, err := commaAssignments(s.scope, s.onDuplicateKeyUpdateAssignments)
+	var sqlString string
+	assignments := s.getUpsertAssignments(fields)
+	if s.ignoreConflict || len(assignments) > 0 {
+		statement.ConflictFields, err = getFieldsSQL(s.scope, s.conflictFields)
+		if err != nil {
+			return "", err
+		}
+		if !s.ignoreConflict {
+			statement.Assignments, err = commaAssignments(s.scope, assignments)
+			if err != nil {
+				return "", err
+			}
+		}
+		sqlString, err = s.scope.getDialect().Upsert(statement)
 		if err != nil {
 			return "", err
 		}
-		sqlString += " ON DUPLICATE KEY UPDATE " + assignmentsSql
+	} else {
+		sqlString = insertSQL(s.method, statement.Table, statement.Fields, statement.source())
+	}
+
+	returningSql, err := getReturningSQL(s.scope, s.returning)
+	if err != nil {
+		return "", err
 	}
+	sqlString += returningSql
 
 	return sqlString, nil
 }
 
+func (s insertStatus) Returning(fields ...Field) returningWithFields {
+	s.returning = fields
+	return returningStatus{statement: s, database: s.scope.Database}
+}
+
 func (s insertStatus) WithContext(ctx context.Context) toInsertFinal {
 	s.ctx = ctx
 	return s
 }
 
 func (s insertStatus) Execute() (result sql.Result, err error) {
-	sqlString, err := s.GetSQL()
+	if s.ctx, err = s.getShardContext(s.ctx); err != nil {
+		return nil, err
+	}
+	models, err := s.getModelsToWriteBack()
+	if err != nil {
+		return nil, err
+	}
+	if len(models) > 0 {
+		return s.executeAndWriteBack(models)
+	}
+
+	sqlString, args, err := s.GetSQLWithArgs()
 	if err != nil {
 		return nil, err
 	}
-	return s.scope.Database.ExecuteContext(s.ctx, sqlString)
+	return s.scope.Database.ExecuteContext(s.ctx, sqlString, args...)
 }
@@ -96,9 +96,46 @@ func TestInsert(t *testing.T) {
 		Execute(); err != nil {
 		t.Error(err)
 	}
-	assertLastSql(t, "INSERT INTO `table1` (`field1`)"+
-		" VALUES (1), (2)"+
-		" ON DUPLICATE KEY UPDATE `field1` = `field1`")
+	assertLastSql(t, "INSERT IGNORE INTO `table1` (`field1`) VALUES (1), (2)")
+
+	if _, err := db.InsertInto(Table1).Fields(field1, field2).
+		Values(1, 2).
+		OnConflict(field1).DoUpdate().Set(field2, 3).
+		Execute(); err != nil {
+		t.Error(err)
+	}
+	assertLastSql(t, "INSERT INTO `table1` (`field1`, `field2`)"+
+		" VALUES (1, 2)"+
+		" ON DUPLICATE KEY UPDATE `field2` = 3")
+
+	if _, err := db.InsertInto(Table1).Fields(field1, field2).
+		Values(1, 2).
+		OnDuplicateKeyUpdate().Set(field2, field2.Add(Inserted(field2))).
+		Execute(); err != nil {
+		t.Error(err)
+	}
+	assertLastSql(t, "INSERT INTO `table1` (`field1`, `field2`)"+
+		" VALUES (1, 2)"+
+		" ON DUPLICATE KEY UPDATE `field2` = `field2` + VALUES(`field2`)")
+
+	if _, err := db.InsertInto(Test).
+		Values(1, 2).
+		OnDuplicateKeyUpdate().SetAllExcept(Test.F1).
+		Execute(); err != nil {
+		t.Error(err)
+	}
+	assertLastSql(t, "INSERT INTO `test` (`f1`, `f2`)"+
+		" VALUES (1, 2)"+
+		" ON DUPLICATE KEY UPDATE `f2` = VALUES(`f2`)")
+
+	rowAliasDb := UseWithDialect(nil, MySQLDialect{RowAlias: true})
+	sql, _ := rowAliasDb.InsertInto(Table1).Fields(field1, field2).
+		Values(1, 2).
+		OnConflict().DoUpdate().SetAllExcept().
+		GetSQL()
+	assertEqual(t, sql, "INSERT INTO `table1` (`field1`, `field2`)"+
+		" VALUES (1, 2) AS `new`"+
+		" ON DUPLICATE KEY UPDATE `field1` = `new`.`field1`, `field2` = `new`.`field2`")
 
 	model := &TestModel{
 		F1: 1,
@@ -122,6 +159,33 @@ func TestInsert(t *testing.T) {
 		t.Error("should get error here")
 	}
 
+	if _, err := db.InsertInto(Test).Fields(Test.F1, Test.F2).
+		Select(db.Select(field1, field2).From(Table1).Where(field1.GreaterThan(1))).
+		OnDuplicateKeyUpdate().SetAllExcept(Test.F1).
+		Execute(); err != nil {
+		t.Error(err)
+	}
+	assertLastSql(t, "INSERT INTO `test` (`f1`, `f2`)"+
+		" SELECT `field1`, `field2` FROM `table1` WHERE `field1` > 1"+
+		" ON DUPLICATE KEY UPDATE `f2` = VALUES(`f2`)")
+
+	if _, err := db.InsertInto(Test).Select(db.SelectFrom(Test)).Execute(); err != nil {
+		t.Error(err)
+	}
+	assertLastSql(t, "INSERT INTO `test` (`f1`, `f2`) SELECT * FROM `test`")
+
+	if _, err := db.InsertInto(Test).Fields(Test.F1).Select(db.Select(field1, field2).From(Table1)).Execute(); err == nil {
+		t.Error("should get error here")
+	}
+
+	rowAliasSql, _ := UseWithDialect(nil, MySQLDialect{RowAlias: true}).InsertInto(Test).
+		Select(db.Select(field1, field2).From(Table1)).
+		OnDuplicateKeyUpdate().SetAllExcept(Test.F1).
+		GetSQL()
+	assertEqual(t, rowAliasSql, "INSERT INTO `test` (`f1`, `f2`)"+
+		" SELECT * FROM (SELECT `field1`, `field2` FROM `table1`) AS `new` (`f1`, `f2`)"+
+		" ON DUPLICATE KEY UPDATE `f2` = `new`.`f2`")
+
 	if _, err := db.ReplaceInto(Test).Values(1, 2).Execute(); err != nil {
 		t.Error(err)
 	}
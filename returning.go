@@ -0,0 +1,104 @@
+package sqlingo
+
+import (
+	"context"
+)
+
+type returningStatement interface {
+	buildSQL(args *argList) (string, error)
+	getShardContext(ctx context.Context) (context.Context, error)
+}
+
+// returningStatus executes an INSERT, UPDATE or DELETE statement with RETURNING clause as a query.
+type returningStatus struct {
+	statement returningStatement
+	database  *database
+	ctx       context.Context
+}
+
+type returningWithFields interface {
+	toReturningWithContext
+	toReturningFinal
+}
+
+type toReturningWithContext interface {
+	WithContext(ctx context.Context) toReturningFinal
+}
+
+type toReturningFinal interface {
+	GetSQL() (string, error)
+	GetSQLWithArgs() (string, []interface{}, error)
+	FetchFirst(dest ...interface{}) (bool, error)
+	FetchExactlyOne(dest ...interface{}) error
+	FetchAll(dest ...interface{}) (rows int, err error)
+	FetchCursor() (Cursor, error)
+}
+
+func getReturningSQL(scope scope, fields []Field) (string, error) {
+	if len(fields) == 0 {
+		return "", nil
+	}
+	fieldsSql, err := commaFields(scope, fields)
+	if err != nil {
+		return "", err
+	}
+	return scope.getDialect().Returning(fieldsSql)
+}
+
+func (s returningStatus) WithContext(ctx context.Context) toReturningFinal {
+	s.ctx = ctx
+	return s
+}
+
+func (s returningStatus) GetSQL() (string, error) {
+	sql, _, err := s.GetSQLWithArgs()
+	return sql, err
+}
+
+func (s returningStatus) GetSQLWithArgs() (string, []interface{}, error) {
+	args := newArgList(s.database)
+	sql, err := s.statement.buildSQL(args)
+	if err != nil {
+		return "", nil, err
+	}
+	return sql, args.getValues(), nil
+}
+
+func (s returningStatus) FetchCursor() (Cursor, error) {
+	ctx, err := s.statement.getShardContext(s.ctx)
+	if err != nil {
+		return nil, err
+	}
+	sqlString, args, err := s.GetSQLWithArgs()
+	if err != nil {
+		return nil, err
+	}
+	return s.database.QueryContext(ctx, sqlString, args...)
+}
+
+func (s returningStatus) FetchFirst(dest ...interface{}) (ok bool, err error) {
+	cursor, err := s.FetchCursor()
+	if err != nil {
+		return
+	}
+	defer cursor.Close()
+	return fetchFirst(cursor, dest...)
+}
+
+func (s returningStatus) FetchExactlyOne(dest ...interface{}) (err error) {
+	cursor, err := s.FetchCursor()
+	if err != nil {
+		return
+	}
+	defer cursor.Close()
+	return fetchExactlyOne(cursor, dest...)
+}
+
+func (s returningStatus) FetchAll(dest ...interface{}) (rows int, err error) {
+	cursor, err := s.FetchCursor()
+	if err != nil {
+		return
+	}
+	defer cursor.Close()
+	return fetchAll(cursor, dest...)
+}
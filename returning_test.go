@@ -0,0 +1,80 @@
+package sqlingo
+
+import (
+	"context"
+	"testing"
+)
+
+func TestReturning(t *testing.T) {
+	postgres := UseWithDialect(nil, dialectPostgres)
+
+	sql, _ := postgres.InsertInto(Table1).Fields(field1, field2).Values(1, 2).Returning(field1).GetSQL()
+	assertEqual(t, sql, `INSERT INTO "table1" ("field1", "field2") VALUES (1, 2) RETURNING "field1"`)
+
+	sql, _ = postgres.InsertInto(Table1).Fields(field1).Values(1).
+		OnConflict(field1).DoUpdate().Set(field2, 2).
+		Returning(field1, field2).GetSQL()
+	assertEqual(t, sql, `INSERT INTO "table1" ("field1") VALUES (1) ON CONFLICT ("field1") DO UPDATE SET "field2" = 2 RETURNING "field1", "field2"`)
+	if _, err := postgres.InsertInto(Table1).Fields(field1).Values(1).
+		OnDuplicateKeyUpdate().Set(field2, 2).
+		Returning(field1, field2).GetSQL(); err == nil {
+		t.Error("should get error here")
+	}
+
+	sql, _ = postgres.Update(Table1).Set(field1, 10).Where(field2.Equals(2)).Returning(field1).GetSQL()
+	assertEqual(t, sql, `UPDATE "table1" SET "field1" = 10 WHERE "field2" = 2 RETURNING "field1"`)
+
+	sql, _ = postgres.DeleteFrom(Table1).Where(field2.Equals(2)).Returning(field1, field2).GetSQL()
+	assertEqual(t, sql, `DELETE FROM "table1" WHERE "field2" = 2 RETURNING "field1", "field2"`)
+
+	sqlite := UseWithDialect(nil, dialectSqlite3)
+	sql, _ = sqlite.DeleteFrom(Table1).Where(field2.Equals(2)).OrderBy(field1).Limit(1).Returning(field1).GetSQL()
+	assertEqual(t, sql, `DELETE FROM "table1" WHERE "field2" = 2 RETURNING "field1" ORDER BY "field1" LIMIT 1`)
+
+	for _, db := range []Database{newMockDatabase(), UseWithDialect(nil, dialectMSSQL)} {
+		if _, err := db.InsertInto(Table1).Fields(field1).Values(1).Returning(field1).GetSQL(); err == nil {
+			t.Error("should get error here")
+		}
+		if _, err := db.Update(Table1).Set(field1, 1).Where(True()).Returning(field1).GetSQL(); err == nil {
+			t.Error("should get error here")
+		}
+	}
+}
+
+func TestReturningFetch(t *testing.T) {
+	db := newParameterizedMockDatabase(dialectPostgres)
+
+	sharedMockConn.columnCount = 2
+	defer func() {
+		sharedMockConn.columnCount = 7
+	}()
+
+	var f1s []string
+	var f2s []int
+	rows, err := db.InsertInto(Table1).Fields(field1, field2).Values(1, 2).Returning(field1, field2).FetchAll(&f1s, &f2s)
+	if err != nil {
+		t.Error(err)
+	}
+	assertLastSql(t, `INSERT INTO "table1" ("field1", "field2") VALUES ($1, $2) RETURNING "field1", "field2"`)
+	assertLastArgs(t, int64(1), int64(2))
+	if rows != 10 || len(f1s) != 10 || len(f2s) != 10 {
+		t.Error(rows, f1s, f2s)
+	}
+
+	var f1 string
+	var f2 int
+	if ok, err := db.Update(Table1).Set(field1, 10).Where(field2.Equals(2)).
+		Returning(field1, field2).WithContext(context.Background()).FetchFirst(&f1, &f2); !ok || err != nil {
+		t.Error(ok, err)
+	}
+	assertLastSql(t, `UPDATE "table1" SET "field1" = $1 WHERE "field2" = $2 RETURNING "field1", "field2"`)
+
+	if err := db.DeleteFrom(Table1).Where(True()).Returning(field1, field2).FetchExactlyOne(&f1, &f2); err == nil {
+		t.Error("should get error here")
+	}
+	assertLastSql(t, `DELETE FROM "table1" RETURNING "field1", "field2"`)
+
+	if _, err := newMockDatabase().DeleteFrom(Table1).Where(True()).Returning(field1).FetchCursor(); err == nil {
+		t.Error("should get error here")
+	}
+}
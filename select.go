@@ -4,7 +4,6 @@ import (
 	"context"
 	"errors"
 	"reflect"
-	"strconv"
 	"strings"
 )
 
@@ -132,11 +131,14 @@ type toSelectFinal interface {
 	Exists() (bool, error)
 	Count() (int, error)
 	GetSQL() (string, error)
+	GetSQLWithArgs() (string, []interface{}, error)
 	FetchFirst(out ...interface{}) (bool, error)
 	FetchExactlyOne(out ...interface{}) error
 	FetchAll(dest ...interface{}) (rows int, err error)
 	FetchCursor() (Cursor, error)
 	FetchSeq() func(yield func(row Scanner) bool) // use with "range over function" in Go 1.22
+
+	buildSQL(args *argList) (string, error)
 }
 
 type join struct {
@@ -162,7 +164,7 @@ type selectStatus struct {
 	limit     *int
 	offset    int
 	ctx       context.Context
-	lock      string
+	lock      LockMode
 }
 
 type errorScanner struct {
@@ -283,6 +285,25 @@ func (d *database) Select(fields ...interface{}) selectWithFields {
 	}
 }
 
+// getFieldCount returns the number of the selected fields, or false if it's unknown.
+func (s selectStatus) getFieldCount() (int, bool) {
+	if len(s.base.fields) > 0 {
+		return len(s.base.fields), true
+	}
+	if s.base.scope.lastJoin != nil {
+		return 0, false
+	}
+	count := 0
+	for _, table := range s.base.scope.Tables {
+		fields := table.GetFields()
+		if len(fields) == 0 {
+			return 0, false
+		}
+		count += len(fields)
+	}
+	return count, count > 0
+}
+
 func (s selectStatus) From(tables ...Table) selectWithTables {
 	activeSelectBase(&s).scope.Tables = tables
 	return s
@@ -398,6 +419,12 @@ func (s selectStatus) Offset(offset int) selectWithOffset {
 }
 
 func (s selectStatus) Count() (count int, err error) {
+	if scattered, err := s.isScattered(s.ctx); err != nil || scattered {
+		if err != nil {
+			return 0, err
+		}
+		return s.countScattered(s.ctx)
+	}
 	if s.lastUnion == nil && len(s.base.groupBys) == 0 && s.limit == nil {
 		if s.base.distinct {
 			fields := s.base.fields
@@ -418,8 +445,9 @@ func (s selectStatus) Count() (count int, err error) {
 		if !s.base.distinct {
 			s.base.fields = []Field{staticExpression("1", 0, false)}
 		}
-		_, err = s.base.scope.Database.Select(Function("COUNT", 1)).
+		_, err = s.base.scope.Database.Select(staticExpression("COUNT(1)", 0, false)).
 			From(s.asDerivedTable("t")).
+			WithContext(s.getOuterContext()).
 			FetchFirst(&count)
 	}
 
@@ -427,22 +455,22 @@ func (s selectStatus) Count() (count int, err error) {
 }
 
 func (s selectStatus) LockInShareMode() selectWithLock {
-	s.lock = " LOCK IN SHARE MODE"
+	s.lock = LockInShareMode
 	return s
 }
 
 func (s selectStatus) ForUpdate() selectWithLock {
-	s.lock = " FOR UPDATE"
+	s.lock = LockForUpdate
 	return s
 }
 
 func (s selectStatus) ForUpdateNoWait() selectWithLock {
-	s.lock = " FOR UPDATE NOWAIT"
+	s.lock = LockForUpdateNoWait
 	return s
 }
 
 func (s selectStatus) ForUpdateSkipLocked() selectWithLock {
-	s.lock = " FOR UPDATE SKIP LOCKED"
+	s.lock = LockForUpdateSkipLocked
 	return s
 }
 
@@ -454,11 +482,29 @@ func (s selectStatus) asDerivedTable(name string) Table {
 }
 
 func (s selectStatus) Exists() (exists bool, err error) {
-	_, err = s.base.scope.Database.Select(command("EXISTS", s)).FetchFirst(&exists)
+	if scattered, err := s.isScattered(s.ctx); err != nil || scattered {
+		if err != nil {
+			return false, err
+		}
+		return s.existsScattered(s.ctx)
+	}
+	condition := command("EXISTS", s)
+	condition.isBool = true
+	_, err = s.base.scope.Database.Select(condition).WithContext(s.getOuterContext()).FetchFirst(&exists)
 	return
 }
 
-func (s selectBase) buildSelectBase(sb *strings.Builder) error {
+// getOuterContext returns the context of the select wrapping this one,
+// which is sent to the primary of a cluster if this one is locking.
+func (s selectStatus) getOuterContext() context.Context {
+	if s.lock != LockNone && s.base.scope.Database.replicas != nil {
+		return WithPrimary(s.ctx)
+	}
+	return s.ctx
+}
+
+func (s selectBase) buildSelectBase(sb *strings.Builder, args *argList, tableHint string) error {
+	s.scope.args = args
 	sb.WriteString("SELECT ")
 	if s.distinct {
 		sb.WriteString("DISTINCT ")
@@ -492,33 +538,13 @@ func (s selectBase) buildSelectBase(sb *strings.Builder) error {
 	sb.WriteString(fieldsSql)
 
 	if len(s.scope.Tables) > 0 {
-		fromSql := commaTables(s.scope, s.scope.Tables)
+		fromSql := commaTables(s.scope, s.scope.Tables, tableHint)
 		sb.WriteString(" FROM ")
 		sb.WriteString(fromSql)
 	}
 
-	if s.scope.lastJoin != nil {
-		var joins []*join
-		for j := s.scope.lastJoin; j != nil; j = j.previous {
-			joins = append(joins, j)
-		}
-		for i := len(joins) - 1; i >= 0; i-- {
-			join := joins[i]
-			sb.WriteString(" ")
-			sb.WriteString(join.prefix)
-			sb.WriteString("JOIN ")
-			sb.WriteString(join.table.GetSQL(s.scope))
-			// cause on isn't a required part of join when using natural join,
-			// so move it to if statement
-			if join.on != nil {
-				onSql, err := join.on.GetSQL(s.scope)
-				if err != nil {
-					return err
-				}
-				sb.WriteString(" ON ")
-				sb.WriteString(onSql)
-			}
-		}
+	if err := appendJoins(sb, s.scope, getJoins(s.scope.lastJoin), tableHint); err != nil {
+		return err
 	}
 
 	if err := appendWhere(sb, s.scope, s.where); err != nil {
@@ -546,11 +572,32 @@ func (s selectBase) buildSelectBase(sb *strings.Builder) error {
 	return nil
 }
 
+// GetSQL returns the SQL string of the statement.
+// In parameterized query mode, values are replaced by placeholders; use GetSQLWithArgs to get them as well.
 func (s selectStatus) GetSQL() (string, error) {
+	sql, _, err := s.GetSQLWithArgs()
+	return sql, err
+}
+
+// GetSQLWithArgs returns the SQL string and the bound arguments of the statement.
+// The arguments are always empty unless parameterized query mode is enabled.
+func (s selectStatus) GetSQLWithArgs() (string, []interface{}, error) {
+	args := newArgList(s.base.scope.Database)
+	sql, err := s.buildSQL(args)
+	if err != nil {
+		return "", nil, err
+	}
+	return sql, args.getValues(), nil
+}
+
+func (s selectStatus) buildSQL(args *argList) (string, error) {
 	var sb strings.Builder
 	sb.Grow(128)
 
-	if err := s.base.buildSelectBase(&sb); err != nil {
+	s.base.scope.args = args
+	dialect := s.base.scope.getDialect()
+	tableHint := dialect.TableHint(s.lock)
+	if err := s.base.buildSelectBase(&sb, args, tableHint); err != nil {
 		return "", err
 	}
 
@@ -565,7 +612,7 @@ func (s selectStatus) GetSQL() (string, error) {
 		} else {
 			sb.WriteString(" UNION ")
 		}
-		if err := union.base.buildSelectBase(&sb); err != nil {
+		if err := union.base.buildSelectBase(&sb, args, tableHint); err != nil {
 			return "", err
 		}
 	}
@@ -579,17 +626,8 @@ func (s selectStatus) GetSQL() (string, error) {
 		sb.WriteString(orderBySql)
 	}
 
-	if s.limit != nil {
-		sb.WriteString(" LIMIT ")
-		sb.WriteString(strconv.Itoa(*s.limit))
-	}
-
-	if s.offset != 0 {
-		sb.WriteString(" OFFSET ")
-		sb.WriteString(strconv.Itoa(s.offset))
-	}
-
-	sb.WriteString(s.lock)
+	sb.WriteString(dialect.LimitOffset(getLimit(s.limit), s.offset, len(s.orderBys) > 0))
+	sb.WriteString(dialect.Lock(s.lock))
 
 	return sb.String(), nil
 }
@@ -600,12 +638,23 @@ func (s selectStatus) WithContext(ctx context.Context) toSelectFinal {
 }
 
 func (s selectStatus) FetchCursor() (Cursor, error) {
-	sqlString, err := s.GetSQL()
+	if scattered, err := s.isScattered(s.ctx); err != nil || scattered {
+		if err != nil {
+			return nil, err
+		}
+		return s.scatter(s.ctx)
+	}
+	sqlString, args, err := s.GetSQLWithArgs()
 	if err != nil {
 		return nil, err
 	}
 
-	cursor, err := s.base.scope.Database.QueryContext(s.ctx, sqlString)
+	ctx := s.ctx
+	if s.base.scope.Database.replicas != nil && s.lock == LockNone {
+		// could be sent to a replica of the cluster
+		ctx = withReadOnly(ctx)
+	}
+	cursor, err := s.base.scope.Database.QueryContext(ctx, sqlString, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -618,7 +667,28 @@ func (s selectStatus) FetchFirst(dest ...interface{}) (ok bool, err error) {
 		return
 	}
 	defer cursor.Close()
+	return fetchFirst(cursor, dest...)
+}
 
+func (s selectStatus) FetchExactlyOne(dest ...interface{}) (err error) {
+	cursor, err := s.FetchCursor()
+	if err != nil {
+		return
+	}
+	defer cursor.Close()
+	return fetchExactlyOne(cursor, dest...)
+}
+
+func (s selectStatus) FetchAll(dest ...interface{}) (rows int, err error) {
+	cursor, err := s.FetchCursor()
+	if err != nil {
+		return
+	}
+	defer cursor.Close()
+	return fetchAll(cursor, dest...)
+}
+
+func fetchFirst(cursor Cursor, dest ...interface{}) (ok bool, err error) {
 	for cursor.Next() {
 		err = cursor.Scan(dest...)
 		if err != nil {
@@ -631,13 +701,7 @@ func (s selectStatus) FetchFirst(dest ...interface{}) (ok bool, err error) {
 	return
 }
 
-func (s selectStatus) FetchExactlyOne(dest ...interface{}) (err error) {
-	cursor, err := s.FetchCursor()
-	if err != nil {
-		return
-	}
-	defer cursor.Close()
-
+func fetchExactlyOne(cursor Cursor, dest ...interface{}) (err error) {
 	hasResult := false
 	for cursor.Next() {
 		if hasResult {
@@ -655,7 +719,7 @@ func (s selectStatus) FetchExactlyOne(dest ...interface{}) (err error) {
 	return
 }
 
-func (s selectStatus) fetchAllAsMap(cursor Cursor, mapType reflect.Type) (mapValue reflect.Value, err error) {
+func fetchAllAsMap(cursor Cursor, mapType reflect.Type) (mapValue reflect.Value, err error) {
 	mapValue = reflect.MakeMap(mapType)
 	key := reflect.New(mapType.Key())
 	elem := reflect.New(mapType.Elem())
@@ -671,13 +735,7 @@ func (s selectStatus) fetchAllAsMap(cursor Cursor, mapType reflect.Type) (mapVal
 	return
 }
 
-func (s selectStatus) FetchAll(dest ...interface{}) (rows int, err error) {
-	cursor, err := s.FetchCursor()
-	if err != nil {
-		return
-	}
-	defer cursor.Close()
-
+func fetchAll(cursor Cursor, dest ...interface{}) (rows int, err error) {
 	count := len(dest)
 	values := make([]reflect.Value, count)
 	for i, item := range dest {
@@ -696,7 +754,7 @@ func (s selectStatus) FetchAll(dest ...interface{}) (rows int, err error) {
 				return
 			}
 			var mapValue reflect.Value
-			mapValue, err = s.fetchAllAsMap(cursor, val.Type())
+			mapValue, err = fetchAllAsMap(cursor, val.Type())
 			if err != nil {
 				return
 			}
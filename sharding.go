@@ -0,0 +1,791 @@
+package sqlingo
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ShardKeyExtractor returns the shard key of a row of the table, from the values in the order of the fields of the table.
+// The values of the fields not inserted are nil.
+type ShardKeyExtractor func(values []interface{}) (interface{}, error)
+
+// ShardRouter returns the index of the shard for the shard key.
+type ShardRouter func(key interface{}) (int, error)
+
+// ShardedDatabase is the interface of the tables split over several databases by the shard key.
+// The shard of a statement is chosen by the shard key in the context set by WithShardKey,
+// or by the key extracted from the inserted rows. The statements on the sharded tables without a shard key are refused,
+// except for SELECT which is scattered to all the shards if enabled by EnableScatterGather.
+// The statements on the other tables, the raw queries and the transactions without a shard key are sent to the first shard.
+// In the transaction of a shard, the statements whose shard key, given or extracted, belongs to another shard are refused.
+type ShardedDatabase interface {
+	Database
+
+	// GetShards returns the underlying sql.DB objects of the shards.
+	GetShards() []*sql.DB
+	// SetShardKeyExtractor marks the table as sharded, and sets the function to extract the shard key from the inserted rows.
+	SetShardKeyExtractor(table Table, extractor ShardKeyExtractor)
+	// EnableScatterGather enables or disables running SELECT without a shard key on all the shards.
+	// The rows are merged in the order of ORDER BY, with LIMIT and OFFSET applied to the merged rows.
+	// ORDER BY is limited to the columns of numbers, booleans, dates and times, and binary strings,
+	// as the order of the other strings depends on the collation of the database.
+	// The numbers are compared exactly, and the dates and times are compared as instants.
+	// SELECT with UNION, GROUP BY or DISTINCT is refused as the rows cannot be merged,
+	// and the aggregate functions are computed for each shard separately.
+	EnableScatterGather(enableScatterGather bool)
+}
+
+// shardSet is shared by the sharded database and the database of each shard.
+type shardSet struct {
+	dbs        []*sql.DB
+	stmtCaches []*stmtCache
+	router     ShardRouter
+
+	mutex         sync.RWMutex
+	extractors    map[string]ShardKeyExtractor
+	scatterGather bool
+}
+
+type shardedDatabase struct {
+	*database
+}
+
+// Sharded uses existing *sql.DB handles of the shards as a sharded database, with the router choosing the shard by the key.
+// The dialect is chosen by the driver name as Use.
+func Sharded(driverName string, router ShardRouter, shards ...*sql.DB) ShardedDatabase {
+	var firstShard *sql.DB
+	if len(shards) > 0 {
+		firstShard = shards[0]
+	}
+	d := Use(driverName, firstShard).(*database)
+	d.shards = &shardSet{
+		dbs:        shards,
+		stmtCaches: make([]*stmtCache, len(shards)),
+		router:     router,
+		extractors: make(map[string]ShardKeyExtractor),
+	}
+	for i := range shards {
+		d.shards.stmtCaches[i] = newStmtCache()
+	}
+	return shardedDatabase{database: d}
+}
+
+func (d shardedDatabase) GetShards() []*sql.DB {
+	return d.shards.dbs
+}
+
+func (d shardedDatabase) SetShardKeyExtractor(table Table, extractor ShardKeyExtractor) {
+	d.shards.mutex.Lock()
+	defer d.shards.mutex.Unlock()
+	if extractor == nil {
+		delete(d.shards.extractors, table.GetName())
+	} else {
+		d.shards.extractors[table.GetName()] = extractor
+	}
+}
+
+func (d shardedDatabase) EnableScatterGather(enableScatterGather bool) {
+	d.shards.mutex.Lock()
+	defer d.shards.mutex.Unlock()
+	d.shards.scatterGather = enableScatterGather
+}
+
+func (s *shardSet) getExtractor(table Table) ShardKeyExtractor {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.extractors[table.GetName()]
+}
+
+func (s *shardSet) isScatterGatherEnabled() bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.scatterGather
+}
+
+func (s *shardSet) route(key interface{}) (int, error) {
+	index, err := s.router(key)
+	if err != nil {
+		return 0, err
+	}
+	if index < 0 || index >= len(s.dbs) {
+		return 0, fmt.Errorf("shard %d of key %v out of range", index, key)
+	}
+	return index, nil
+}
+
+type shardKeyContextKey struct{}
+
+type shardIndexContextKey struct{}
+
+// WithShardKey returns a context in which the statements and the transactions are sent to the shard of the key.
+func WithShardKey(ctx context.Context, key interface{}) context.Context {
+	return context.WithValue(ctx, shardKeyContextKey{}, key)
+}
+
+// withShardIndex returns a context in which the statements are sent to the shard of the index, used by scatter-gather.
+func withShardIndex(ctx context.Context, index int) context.Context {
+	return context.WithValue(ctx, shardIndexContextKey{}, index)
+}
+
+// isRoutedToShard tells whether the context determines the shard, by a shard key or a transaction.
+func isRoutedToShard(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	if _, ok := ctx.Value(txContextKey{}).(Transaction); ok {
+		return true
+	}
+	return ctx.Value(shardKeyContextKey{}) != nil || ctx.Value(shardIndexContextKey{}) != nil
+}
+
+// getShard returns the database of the shard for the context, or d itself if it's not sharded or in a transaction.
+// In the transaction of a shard, the shard key of the context should belong to the same shard.
+func (d *database) getShard(ctx context.Context) (*database, error) {
+	if d.tx != nil {
+		return d, d.checkTxShard(ctx)
+	}
+	if d.shards == nil {
+		return d, nil
+	}
+	index := 0
+	if ctx != nil {
+		if tx, ok := ctx.Value(txContextKey{}).(*database); ok {
+			// the transaction begun in a shard
+			return tx, tx.checkTxShard(ctx)
+		}
+		if shardIndex, ok := ctx.Value(shardIndexContextKey{}).(int); ok {
+			index = shardIndex
+		} else if key := ctx.Value(shardKeyContextKey{}); key != nil {
+			var err error
+			if index, err = d.shards.route(key); err != nil {
+				return nil, err
+			}
+		}
+	}
+	shard := *d
+	shard.db = d.shards.dbs[index]
+	shard.stmtCache = d.shards.stmtCaches[index]
+	shard.shards = nil
+	shard.shardOf = d.shards
+	shard.shardIndex = index
+	return &shard, nil
+}
+
+// checkTxShard returns an error if the shard key of the context belongs to another shard than the one of the transaction,
+// as the statement cannot be sent to the other shard in the transaction.
+func (d *database) checkTxShard(ctx context.Context) error {
+	if d.shardOf == nil || ctx == nil {
+		return nil
+	}
+	key := ctx.Value(shardKeyContextKey{})
+	if key == nil {
+		return nil
+	}
+	index, err := d.shardOf.route(key)
+	if err != nil {
+		return err
+	}
+	if index != d.shardIndex {
+		return fmt.Errorf("the shard key %v belongs to shard %d, not shard %d of the transaction", key, index, d.shardIndex)
+	}
+	return nil
+}
+
+// getShardTx returns the transaction of a shard in which the statement runs, or nil if there is none.
+func (s scope) getShardTx(ctx context.Context) *database {
+	if s.Database == nil {
+		return nil
+	}
+	if s.Database.tx != nil {
+		if s.Database.shardOf != nil {
+			return s.Database
+		}
+		return nil
+	}
+	if s.Database.shards != nil && ctx != nil {
+		if tx, ok := ctx.Value(txContextKey{}).(*database); ok && tx.shardOf != nil {
+			return tx
+		}
+	}
+	return nil
+}
+
+// getUnroutedTable returns the sharded table of the statement, which cannot be sent to a shard without the shard key.
+// The tables of the scope and its joins are checked, as well as the extra tables.
+func (s scope) getUnroutedTable(ctx context.Context, extraTables []Table) Table {
+	if s.Database == nil || s.Database.shards == nil || s.Database.tx != nil || isRoutedToShard(ctx) {
+		return nil
+	}
+	tables := append(append([]Table{}, s.Tables...), extraTables...)
+	for _, join := range getJoins(s.lastJoin) {
+		tables = append(tables, join.table)
+	}
+	for _, table := range tables {
+		if s.Database.shards.getExtractor(table) != nil {
+			return table
+		}
+	}
+	return nil
+}
+
+func shardKeyRequiredError(table Table) error {
+	return fmt.Errorf("the shard key of table %s is required, use WithShardKey", table.GetName())
+}
+
+// checkShardKey returns an error if the statement on a sharded table cannot be sent to a shard.
+func (s scope) checkShardKey(ctx context.Context) error {
+	if table := s.getUnroutedTable(ctx, nil); table != nil {
+		return shardKeyRequiredError(table)
+	}
+	return nil
+}
+
+func (s updateStatus) getShardContext(ctx context.Context) (context.Context, error) {
+	return ctx, s.scope.checkShardKey(ctx)
+}
+
+func (s deleteStatus) getShardContext(ctx context.Context) (context.Context, error) {
+	return ctx, s.scope.checkShardKey(ctx)
+}
+
+// getShardContext returns the context with the shard key extracted from the inserted rows if the table is sharded,
+// all the rows should belong to the same shard.
+// In the transaction of a shard, the rows are extracted as well to check that they belong to the shard of the transaction.
+func (s insertStatus) getShardContext(ctx context.Context) (context.Context, error) {
+	var shards *shardSet
+	table := s.scope.getUnroutedTable(ctx, nil)
+	if table != nil {
+		if s.selectStatement != nil {
+			return ctx, shardKeyRequiredError(table)
+		}
+		shards = s.scope.Database.shards
+	} else {
+		tx := s.scope.getShardTx(ctx)
+		if tx == nil || s.selectStatement != nil {
+			return ctx, nil
+		}
+		if err := tx.checkTxShard(ctx); err != nil {
+			return ctx, err
+		}
+		shards = tx.shardOf
+		if table = s.scope.Tables[0]; shards.getExtractor(table) == nil {
+			return ctx, nil
+		}
+	}
+	rows, err := s.getRowsInTableOrder()
+	if err != nil {
+		return ctx, err
+	}
+
+	extractor := shards.getExtractor(table)
+	var key interface{}
+	index := -1
+	for _, row := range rows {
+		rowKey, err := extractor(row)
+		if err != nil {
+			return ctx, err
+		}
+		rowIndex, err := shards.route(rowKey)
+		if err != nil {
+			return ctx, err
+		}
+		if index != -1 && rowIndex != index {
+			return ctx, fmt.Errorf("the rows of table %s belong to different shards", table.GetName())
+		}
+		key, index = rowKey, rowIndex
+	}
+	if index == -1 {
+		return ctx, nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return WithShardKey(ctx, key), nil
+}
+
+// getRowsInTableOrder returns the values of the inserted rows in the order of the fields of the table.
+func (s insertStatus) getRowsInTableOrder() ([][]interface{}, error) {
+	table := s.scope.Tables[0]
+	tableFields := table.GetFields()
+	if len(s.models) > 0 {
+		models, err := getModels(s.models)
+		if err != nil {
+			return nil, err
+		}
+		rows := make([][]interface{}, len(models))
+		for i, model := range models {
+			rows[i] = model.GetValues()
+		}
+		return rows, nil
+	}
+
+	indexes := make([]int, len(s.fields))
+	for i, field := range s.fields {
+		if indexes[i] = getFieldIndex(s.scope, tableFields, field); indexes[i] == -1 {
+			return nil, fmt.Errorf("field not found in table %s", table.GetName())
+		}
+	}
+	rows := make([][]interface{}, len(s.values))
+	for i, value := range s.values {
+		values, ok := value.([]interface{})
+		if !ok {
+			return nil, errors.New("unknown values of the row")
+		}
+		if len(s.fields) == 0 {
+			rows[i] = values
+			continue
+		}
+		if len(values) != len(s.fields) {
+			return nil, fmt.Errorf("%d values for %d fields", len(values), len(s.fields))
+		}
+		rows[i] = make([]interface{}, len(tableFields))
+		for j, index := range indexes {
+			rows[i][index] = values[j]
+		}
+	}
+	return rows, nil
+}
+
+// isScattered tells whether the statement is to be scattered to all the shards, as the shard key is not given.
+func (s selectStatus) isScattered(ctx context.Context) (bool, error) {
+	var fieldTables []Table
+	for _, field := range s.base.fields {
+		if table := field.GetTable(); table != nil {
+			fieldTables = append(fieldTables, table)
+		}
+	}
+	table := s.base.scope.getUnroutedTable(ctx, fieldTables)
+	if table == nil {
+		return false, nil
+	}
+	if !s.base.scope.Database.shards.isScatterGatherEnabled() {
+		return false, shardKeyRequiredError(table)
+	}
+	if s.lastUnion != nil || len(s.base.groupBys) > 0 || s.base.distinct {
+		return false, fmt.Errorf("the rows of table %s with UNION, GROUP BY or DISTINCT cannot be merged from the shards, "+
+			"the shard key is required", table.GetName())
+	}
+	return true, nil
+}
+
+// scatter runs the statement on all the shards and merges the rows.
+// The ORDER BY expressions are selected as extra columns to merge the rows in order,
+// and each shard returns up to LIMIT + OFFSET rows, which are skipped and limited after merged.
+func (s selectStatus) scatter(ctx context.Context) (Cursor, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	var descs []bool
+	if len(s.orderBys) > 0 {
+		fields := make(fieldList, len(s.base.fields))
+		copy(fields, s.base.fields)
+		if len(fields) == 0 {
+			for _, table := range s.base.scope.Tables {
+				if _, ok := table.(actualTable); ok {
+					fields = append(fields, table.GetFields()...)
+				} else {
+					fields = append(fields, staticExpression("*", 0, false))
+				}
+			}
+		}
+		for i, order := range s.orderBys {
+			by, desc := order, false
+			if order, ok := order.(orderBy); ok {
+				by, desc = order.by, order.desc
+			}
+			alias := s.base.scope.getDialect().QuoteIdentifier(getScatterOrderAlias(i))
+			fields = append(fields, expression{builder: func(scope scope) (string, error) {
+				bySql, err := by.GetSQL(scope)
+				if err != nil {
+					return "", err
+				}
+				return bySql + " AS " + alias, nil
+			}})
+			descs = append(descs, desc)
+		}
+		s.base.fields = fields
+	}
+
+	limit, offset := s.limit, s.offset
+	if limit != nil {
+		shardLimit := *limit + offset
+		s.limit = &shardLimit
+	}
+	s.offset = 0
+	sqlString, args, err := s.GetSQLWithArgs()
+	if err != nil {
+		return nil, err
+	}
+
+	d := s.base.scope.Database
+	merged := &mergedCursor{
+		descs:     descs,
+		nullsLast: d.dialect.NullsLast(),
+		offset:    offset,
+		limit:     limit,
+		current:   -1,
+	}
+	for i := range d.shards.dbs {
+		cursor, err := d.QueryContext(withShardIndex(ctx, i), sqlString, args...)
+		if err != nil {
+			_ = merged.Close()
+			return nil, err
+		}
+		merged.cursors = append(merged.cursors, cursor)
+	}
+	if len(descs) > 0 {
+		if merged.kinds, err = getMergedColumnKinds(merged.cursors[0], len(descs)); err != nil {
+			_ = merged.Close()
+			return nil, err
+		}
+	}
+	merged.hasRow = make([]bool, len(merged.cursors))
+	merged.keys = make([][][]byte, len(merged.cursors))
+	return merged, nil
+}
+
+// mergedColumnKind tells how the values of an extra column of ORDER BY are compared.
+type mergedColumnKind int
+
+const (
+	mergedBytes mergedColumnKind = iota
+	mergedNumber
+	mergedTime
+)
+
+// getMergedColumnKinds tells how each of the extra columns of ORDER BY is compared, by the column types of the cursor.
+func getMergedColumnKinds(c Cursor, count int) ([]mergedColumnKind, error) {
+	getter, ok := c.(columnsGetter)
+	if !ok {
+		return nil, errors.New("cannot get the columns to merge the rows from the shards")
+	}
+	columnTypes, err := getter.columnTypes()
+	if err != nil {
+		return nil, err
+	}
+	if len(columnTypes) < count {
+		return nil, errors.New("the columns of ORDER BY are missing to merge the rows from the shards")
+	}
+	kinds := make([]mergedColumnKind, count)
+	for i, columnType := range columnTypes[len(columnTypes)-count:] {
+		if kinds[i], err = getMergedColumnKind(columnType.ScanType(), columnType.DatabaseTypeName()); err != nil {
+			return nil, err
+		}
+	}
+	return kinds, nil
+}
+
+var (
+	nullInt64Type   = reflect.TypeOf(sql.NullInt64{})
+	nullInt32Type   = reflect.TypeOf(sql.NullInt32{})
+	nullFloat64Type = reflect.TypeOf(sql.NullFloat64{})
+	nullBoolType    = reflect.TypeOf(sql.NullBool{})
+	nullTimeType    = reflect.TypeOf(sql.NullTime{})
+)
+
+// getMergedColumnKind tells whether the values of the column are compared as numbers, as times, or as bytes otherwise.
+// The strings are refused, as their order depends on the collation of the database,
+// and so are the columns of unknown types.
+func getMergedColumnKind(scanType reflect.Type, databaseTypeName string) (mergedColumnKind, error) {
+	if scanType != nil {
+		switch scanType.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+			return mergedNumber, nil
+		case reflect.Bool:
+			return mergedBytes, nil
+		}
+		switch scanType {
+		case nullInt64Type, nullInt32Type, nullFloat64Type:
+			return mergedNumber, nil
+		case nullBoolType:
+			return mergedBytes, nil
+		case timeType, nullTimeType:
+			return mergedTime, nil
+		}
+	}
+
+	switch strings.TrimPrefix(strings.ToUpper(databaseTypeName), "UNSIGNED ") {
+	case "TINYINT", "SMALLINT", "MEDIUMINT", "INT", "INTEGER", "BIGINT", "INT2", "INT4", "INT8", "YEAR",
+		"DECIMAL", "NUMERIC", "FLOAT", "DOUBLE", "REAL", "FLOAT4", "FLOAT8":
+		return mergedNumber, nil
+	case "DATE", "DATETIME", "DATETIME2", "TIMESTAMP", "TIMESTAMPTZ":
+		return mergedTime, nil
+	case "BOOL", "BOOLEAN",
+		"BINARY", "VARBINARY", "TINYBLOB", "BLOB", "MEDIUMBLOB", "LONGBLOB", "BYTEA":
+		return mergedBytes, nil
+	}
+	if databaseTypeName == "" {
+		databaseTypeName = "unknown"
+	}
+	return mergedBytes, fmt.Errorf("the rows ordered by the column of %s type cannot be merged from the shards "+
+		"in the same order as the database, the shard key is required", databaseTypeName)
+}
+
+func getScatterOrderAlias(index int) string {
+	return "sqlingo_order_" + strconv.Itoa(index)
+}
+
+// countScattered sums up the counts of all the shards.
+func (s selectStatus) countScattered(ctx context.Context) (int, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	limit, offset := s.limit, s.offset
+	if limit != nil {
+		shardLimit := *limit + offset
+		s.limit = &shardLimit
+	}
+	s.offset = 0
+	total := 0
+	for i := range s.base.scope.Database.shards.dbs {
+		count, err := s.WithContext(withShardIndex(ctx, i)).(selectStatus).Count()
+		if err != nil {
+			return 0, err
+		}
+		total += count
+	}
+	total -= offset
+	if total < 0 {
+		total = 0
+	}
+	if limit != nil && total > *limit {
+		total = *limit
+	}
+	return total, nil
+}
+
+// existsScattered tells whether the rows exist in any shard.
+func (s selectStatus) existsScattered(ctx context.Context) (bool, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	for i := range s.base.scope.Database.shards.dbs {
+		exists, err := s.WithContext(withShardIndex(ctx, i)).(selectStatus).Exists()
+		if err != nil || exists {
+			return exists, err
+		}
+	}
+	return false, nil
+}
+
+// columnsGetter is implemented by the cursor of the query.
+type columnsGetter interface {
+	columns() ([]string, error)
+	columnTypes() ([]*sql.ColumnType, error)
+}
+
+// mergedCursor merges the rows from the cursors of the shards.
+type mergedCursor struct {
+	cursors []Cursor
+	// the extra columns of ORDER BY at the end of each row, whether they are descending and how they are compared
+	descs     []bool
+	kinds     []mergedColumnKind
+	nullsLast bool
+	offset    int
+	limit     *int
+
+	started bool
+	hasRow  []bool
+	keys    [][][]byte
+	current int
+	count   int
+	err     error
+}
+
+func (c *mergedCursor) Next() bool {
+	for ; c.offset > 0; c.offset-- {
+		if !c.advance() {
+			return false
+		}
+	}
+	if c.limit != nil && c.count >= *c.limit {
+		c.current = -1
+		return false
+	}
+	if !c.advance() {
+		return false
+	}
+	c.count++
+	return true
+}
+
+// advance moves to the next row in the order of ORDER BY, or in the order of the shards without ORDER BY.
+func (c *mergedCursor) advance() bool {
+	if c.err != nil {
+		return false
+	}
+	if !c.started {
+		c.started = true
+		for i := range c.cursors {
+			c.fetch(i)
+		}
+	} else if c.current != -1 {
+		c.fetch(c.current)
+	}
+	if c.err != nil {
+		return false
+	}
+
+	c.current = -1
+	for i := range c.cursors {
+		if c.hasRow[i] && (c.current == -1 || c.less(i, c.current)) {
+			c.current = i
+		}
+	}
+	if c.err != nil {
+		c.current = -1
+		return false
+	}
+	return c.current != -1
+}
+
+// fetch moves the cursor of the shard to its next row, and reads the extra columns of ORDER BY.
+func (c *mergedCursor) fetch(i int) {
+	c.hasRow[i] = c.cursors[i].Next()
+	if !c.hasRow[i] || len(c.descs) == 0 {
+		return
+	}
+	getter, ok := c.cursors[i].(columnsGetter)
+	if !ok {
+		c.err = errors.New("cannot get the columns to merge the rows from the shards")
+		return
+	}
+	columns, err := getter.columns()
+	if err != nil {
+		c.err = err
+		return
+	}
+	values := make([][]byte, len(columns))
+	pointers := make([]interface{}, len(columns))
+	for j := range values {
+		pointers[j] = &values[j]
+	}
+	if c.err = c.cursors[i].Scan(pointers...); c.err != nil {
+		return
+	}
+	c.keys[i] = values[len(columns)-len(c.descs):]
+}
+
+// less tells whether the row of the shard i is before the one of the shard j, and sets c.err if they cannot be compared.
+func (c *mergedCursor) less(i, j int) bool {
+	for k, desc := range c.descs {
+		result, err := compareMergedValues(c.keys[i][k], c.keys[j][k], c.kinds[k], c.nullsLast)
+		if err != nil {
+			c.err = err
+			return false
+		}
+		if desc {
+			result = -result
+		}
+		if result != 0 {
+			return result < 0
+		}
+	}
+	return false
+}
+
+// compareMergedValues compares the values by the kind of the column. The numbers are compared exactly,
+// including DECIMAL and BIGINT beyond the precision of float64, and the times are compared as instants,
+// which are scanned as text such as "2006-01-02 15:04:05" or RFC 3339 with time zone.
+// NULL is the smallest, or the largest if nullsLast.
+func compareMergedValues(a, b []byte, kind mergedColumnKind, nullsLast bool) (int, error) {
+	switch {
+	case a == nil && b == nil:
+		return 0, nil
+	case a == nil || b == nil:
+		if (a == nil) == nullsLast {
+			return 1, nil
+		}
+		return -1, nil
+	}
+	switch kind {
+	case mergedNumber:
+		numberA, okA := new(big.Rat).SetString(string(a))
+		numberB, okB := new(big.Rat).SetString(string(b))
+		if !okA || !okB {
+			return 0, fmt.Errorf("cannot compare %s and %s as numbers to merge the rows from the shards", a, b)
+		}
+		return numberA.Cmp(numberB), nil
+	case mergedTime:
+		timeA, err := parseMergedTime(string(a))
+		if err != nil {
+			return 0, err
+		}
+		timeB, err := parseMergedTime(string(b))
+		if err != nil {
+			return 0, err
+		}
+		switch {
+		case timeA.Before(timeB):
+			return -1, nil
+		case timeA.After(timeB):
+			return 1, nil
+		default:
+			return 0, nil
+		}
+	default:
+		return bytes.Compare(a, b), nil
+	}
+}
+
+// parseMergedTime parses the time scanned as text, which is a date without time for DATE of MySQL.
+func parseMergedTime(s string) (time.Time, error) {
+	if len(s) == len("2006-01-02") {
+		return time.Parse("2006-01-02", s)
+	}
+	return parseTime(s)
+}
+
+func (c *mergedCursor) Scan(dest ...interface{}) error {
+	if c.err != nil {
+		return c.err
+	}
+	if c.current == -1 {
+		return errors.New("no current row")
+	}
+	if len(dest) > 0 {
+		// skip the extra columns of ORDER BY
+		dest = dest[:len(dest):len(dest)]
+		for range c.descs {
+			dest = append(dest, new([]byte))
+		}
+	}
+	return c.cursors[c.current].Scan(dest...)
+}
+
+func (c *mergedCursor) GetMap() (map[string]value, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	if c.current == -1 {
+		return nil, errors.New("no current row")
+	}
+	result, err := c.cursors[c.current].GetMap()
+	if err != nil {
+		return nil, err
+	}
+	for i := range c.descs {
+		delete(result, getScatterOrderAlias(i))
+	}
+	return result, nil
+}
+
+func (c *mergedCursor) Close() (err error) {
+	for _, cursor := range c.cursors {
+		if closeErr := cursor.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return
+}
@@ -0,0 +1,363 @@
+package sqlingo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newMockShardedDatabase() (ShardedDatabase, *mockReplicaConn, *mockReplicaConn) {
+	shard0, conn0 := newMockReplica("shard0")
+	shard1, conn1 := newMockReplica("shard1")
+	db := Sharded("mysql", func(key interface{}) (int, error) {
+		id, ok := key.(int64)
+		if !ok {
+			return 0, errors.New("unknown key")
+		}
+		return int(id % 2), nil
+	}, shard0, shard1)
+	db.SetShardKeyExtractor(Test, func(values []interface{}) (interface{}, error) {
+		return values[0], nil
+	})
+	return db, conn0, conn1
+}
+
+func TestShardedDatabase(t *testing.T) {
+	db, conn0, conn1 := newMockShardedDatabase()
+	if len(db.GetShards()) != 2 || db.GetDB() != db.GetShards()[0] {
+		t.Error(db.GetShards())
+	}
+
+	// the shard key is extracted from the inserted rows
+	_, _ = db.InsertInto(Test).Models(TestModel{F1: 3, F2: "a"}, &TestModel{F1: 5, F2: "b"}).Execute()
+	assertEqual(t, conn1.lastSql, "INSERT INTO `test` (`f1`, `f2`) VALUES (3, 'a'), (5, 'b')")
+	_, _ = db.InsertInto(Test).Fields(Test.F2, Test.F1).Values("c", int64(4)).Execute()
+	assertEqual(t, conn0.lastSql, "INSERT INTO `test` (`f2`, `f1`) VALUES ('c', 4)")
+	if _, err := db.InsertInto(Test).Models(TestModel{F1: 3}, TestModel{F1: 4}).Execute(); err == nil {
+		t.Error("should get error here")
+	}
+	if _, err := db.InsertInto(Test).Fields(Test.F2).Values("c").Execute(); err == nil {
+		t.Error("should get error here")
+	}
+
+	// the shard key is required for the sharded table
+	if _, err := db.Update(Test).Set(Test.F2, "x").Where(Test.F1.Equals(7)).Execute(); err == nil {
+		t.Error("should get error here")
+	}
+	if _, err := db.DeleteFrom(Test).Where(Test.F1.Equals(7)).Execute(); err == nil {
+		t.Error("should get error here")
+	}
+	if _, err := db.SelectFrom(Test).FetchAll(); err == nil {
+		t.Error("should get error here")
+	}
+	if _, err := db.SelectFrom(Table1).Join(Test).On(Test.F1.Equals(field1)).FetchAll(); err == nil {
+		t.Error("should get error here")
+	}
+
+	ctx := WithShardKey(context.Background(), int64(7))
+	_, _ = db.Update(Test).Set(Test.F2, "x").Where(Test.F1.Equals(7)).WithContext(ctx).Execute()
+	assertEqual(t, conn1.lastSql, "UPDATE `test` SET `f2` = 'x' WHERE `f1` = 7")
+	_, _ = db.DeleteFrom(Test).Where(Test.F1.Equals(8)).WithContext(WithShardKey(context.Background(), int64(8))).Execute()
+	assertEqual(t, conn0.lastSql, "DELETE FROM `test` WHERE `f1` = 8")
+	if _, err := db.DeleteFrom(Test).Where(Test.F1.Equals(8)).WithContext(WithShardKey(context.Background(), "8")).Execute(); err == nil {
+		t.Error("should get error here")
+	}
+
+	// the other tables and the raw queries are sent to the first shard
+	_, _ = db.DeleteFrom(Table1).Where(field1.Equals(1)).Execute()
+	assertEqual(t, conn0.lastSql, "DELETE FROM `table1` WHERE `field1` = 1")
+	_, _ = db.Execute("SELECT 1")
+	assertEqual(t, conn0.lastSql, "SELECT 1")
+
+	// the transactions are begun in the shard of the key
+	_ = db.BeginTx(ctx, nil, func(tx Transaction) error {
+		_, _ = tx.DeleteFrom(Test).Where(Test.F1.Equals(9)).Execute()
+		return nil
+	})
+	assertEqual(t, conn1.lastSql, "DELETE FROM `test` WHERE `f1` = 9")
+	_ = db.EnsureTx(ctx, nil, func(ctx context.Context) error {
+		_, _ = db.DeleteFrom(Test).Where(Test.F1.Equals(11)).WithContext(ctx).Execute()
+		return nil
+	})
+	assertEqual(t, conn1.lastSql, "DELETE FROM `test` WHERE `f1` = 11")
+
+	// the statements of another shard are refused in the transaction
+	conn0.lastSql, conn1.lastSql = "", ""
+	_ = db.EnsureTx(ctx, nil, func(ctx context.Context) error {
+		if _, err := db.DeleteFrom(Test).Where(Test.F1.Equals(8)).WithContext(WithShardKey(ctx, int64(8))).Execute(); err == nil {
+			t.Error("should get error here")
+		}
+		if _, err := db.InsertInto(Test).Models(TestModel{F1: 8}).WithContext(ctx).Execute(); err == nil {
+			t.Error("should get error here")
+		}
+		_, _ = db.InsertInto(Test).Models(TestModel{F1: 17}).WithContext(ctx).Execute()
+		return nil
+	})
+	_ = db.BeginTx(ctx, nil, func(tx Transaction) error {
+		if _, err := tx.Update(Test).Set(Test.F2, "x").Where(Test.F1.Equals(8)).
+			WithContext(WithShardKey(context.Background(), int64(8))).Execute(); err == nil {
+			t.Error("should get error here")
+		}
+		if _, err := tx.InsertInto(Test).Fields(Test.F1).Values(int64(8)).Execute(); err == nil {
+			t.Error("should get error here")
+		}
+		if _, err := tx.ExecuteContext(WithShardKey(context.Background(), int64(8)), "SELECT 1"); err == nil {
+			t.Error("should get error here")
+		}
+		_, _ = tx.InsertInto(Test).Fields(Test.F1).Values(int64(19)).Execute()
+		return nil
+	})
+	assertEqual(t, conn0.lastSql, "")
+	assertEqual(t, conn1.lastSql, "INSERT INTO `test` (`f1`) VALUES (19)")
+	_, _ = db.InsertInto(Test).Models(TestModel{F1: 13}, TestModel{F1: 15}).BatchSize(1).InTransaction().Execute()
+	assertEqual(t, conn1.lastSql, "INSERT INTO `test` (`f1`, `f2`) VALUES (15, '')")
+
+	if _, err := db.BulkLoad(Test, []TestModel{{F1: 3}}); err == nil {
+		t.Error("should get error here")
+	}
+	_, _ = db.BulkLoadContext(ctx, Test, []TestModel{{F1: 3}})
+	assertEqual(t, conn1.lastSql, "INSERT INTO `test` (`f1`, `f2`) VALUES (3, '')")
+
+	db.SetStatementCacheSize(10)
+	db.EnableParameterizedQuery(true)
+	_, _ = db.Execute("SELECT 1")
+	_, _ = db.ExecuteContext(ctx, "SELECT 1")
+	_, _ = db.ExecuteContext(ctx, "SELECT 1")
+	if stats := db.GetStatementCacheStats(); stats.Hits != 1 || stats.Misses != 2 || stats.Size != 2 {
+		t.Error(stats)
+	}
+}
+
+func TestShardedDatabaseScatterGather(t *testing.T) {
+	db, conn0, conn1 := newMockShardedDatabase()
+	db.EnableScatterGather(true)
+
+	cursor, err := db.SelectFrom(Test).Where(Test.F2.Equals("a")).Limit(5).Offset(3).FetchCursor()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := 0
+	for cursor.Next() {
+		rows++
+	}
+	_ = cursor.Close()
+	if rows != 5 {
+		t.Error(rows)
+	}
+	assertEqual(t, conn0.lastSql, "SELECT * FROM `test` WHERE `f2` = 'a' LIMIT 8")
+	assertEqual(t, conn1.lastSql, conn0.lastSql)
+
+	cursor, err = db.SelectFrom(Test).OrderBy(Test.F2, Test.F1.Desc()).FetchCursor()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows = 0
+	for cursor.Next() {
+		if _, err := cursor.GetMap(); err != nil {
+			t.Error(err)
+		}
+		rows++
+	}
+	_ = cursor.Close()
+	if rows != 20 {
+		t.Error(rows)
+	}
+	assertEqual(t, conn0.lastSql, "SELECT *, `f2` AS `sqlingo_order_0`, `f1` AS `sqlingo_order_1` FROM `test` ORDER BY `f2`, `f1` DESC")
+
+	conn0.columnCount, conn1.columnCount = 1, 1
+	defer func() {
+		conn0.columnCount, conn1.columnCount = 11, 11
+	}()
+	if count, err := db.SelectFrom(Test).Count(); count != 2 || err != nil {
+		t.Error(count, err)
+	}
+	if count, err := db.SelectFrom(Test).Limit(3).Offset(1).Count(); count != 1 || err != nil {
+		t.Error(count, err)
+	}
+	if exists, err := db.SelectFrom(Test).Exists(); !exists || err != nil {
+		t.Error(exists, err)
+	}
+
+	// the strings cannot be merged in the order of the collation
+	if _, err := db.Select(Test.F1, Test.F2).From(Test).OrderBy(Test.F2).FetchAll(); err == nil {
+		t.Error("should get error here")
+	}
+
+	if _, err := db.SelectFrom(Test).GroupBy(Test.F2).FetchAll(); err == nil {
+		t.Error("should get error here")
+	}
+	if _, err := db.SelectDistinct(Test.F2).From(Test).Count(); err == nil {
+		t.Error("should get error here")
+	}
+}
+
+type mockMergedCursor struct {
+	rows    [][][]byte
+	current int
+}
+
+func (c *mockMergedCursor) Next() bool {
+	c.current++
+	return c.current <= len(c.rows)
+}
+
+func (c *mockMergedCursor) Scan(dest ...interface{}) error {
+	if len(dest) != len(c.rows[c.current-1]) {
+		return errors.New("wrong number of columns")
+	}
+	for i, value := range c.rows[c.current-1] {
+		*dest[i].(*[]byte) = value
+	}
+	return nil
+}
+
+func (c *mockMergedCursor) GetMap() (map[string]value, error) {
+	return nil, nil
+}
+
+func (c *mockMergedCursor) Close() error {
+	return nil
+}
+
+func (c *mockMergedCursor) columns() ([]string, error) {
+	return []string{"name", "sqlingo_order_0", "sqlingo_order_1"}, nil
+}
+
+func (c *mockMergedCursor) columnTypes() ([]*sql.ColumnType, error) {
+	return nil, nil
+}
+
+func TestMergedCursor(t *testing.T) {
+	row := func(name string, key1 string, key2 []byte) [][]byte {
+		return [][]byte{[]byte(name), []byte(key1), key2}
+	}
+	newCursor := func(limit *int) *mergedCursor {
+		cursors := []Cursor{
+			&mockMergedCursor{rows: [][][]byte{row("a", "1", []byte("8")), row("c", "2", []byte("9")), row("f", "10", nil)}},
+			&mockMergedCursor{rows: [][][]byte{row("b", "1", []byte("7")), row("d", "2", []byte("10")), row("e", "3", nil)}},
+		}
+		return &mergedCursor{
+			cursors: cursors,
+			descs:   []bool{false, true},
+			kinds:   []mergedColumnKind{mergedNumber, mergedNumber},
+			hasRow:  make([]bool, len(cursors)),
+			keys:    make([][][]byte, len(cursors)),
+			current: -1,
+			offset:  1,
+			limit:   limit,
+		}
+	}
+
+	var names string
+	cursor := newCursor(nil)
+	cursor.kinds[1] = mergedBytes
+	for cursor.Next() {
+		var name []byte
+		_ = cursor.Scan(&name)
+		names += string(name)
+	}
+	// "9" is after "10" as bytes
+	assertEqual(t, names, "bcdef")
+
+	names = ""
+	cursor = newCursor(nil)
+	for cursor.Next() {
+		var name []byte
+		if err := cursor.Scan(&name); err != nil {
+			t.Error(err)
+		}
+		names += string(name)
+	}
+	assertEqual(t, names, "bdcef")
+
+	limit := 2
+	names = ""
+	cursor = newCursor(&limit)
+	for cursor.Next() {
+		var name []byte
+		_ = cursor.Scan(&name)
+		names += string(name)
+	}
+	assertEqual(t, names, "bd")
+	var name []byte
+	if err := cursor.Scan(&name); err == nil {
+		t.Error("should get error here")
+	}
+
+	// the values which cannot be compared stop the merge
+	cursor = newCursor(nil)
+	cursor.kinds[0] = mergedTime
+	if cursor.Next() {
+		t.Error("should not get row here")
+	}
+	if err := cursor.Scan(&name); err == nil {
+		t.Error("should get error here")
+	}
+}
+
+func TestCompareMergedValues(t *testing.T) {
+	for _, item := range []struct {
+		a, b      []byte
+		kind      mergedColumnKind
+		nullsLast bool
+		expected  int
+	}{
+		{[]byte("2"), []byte("10"), mergedNumber, false, -1},
+		{[]byte("2"), []byte("10"), mergedBytes, false, 1},
+		{[]byte("10"), []byte("10.0"), mergedNumber, false, 0},
+		{[]byte("9007199254740993"), []byte("9007199254740992"), mergedNumber, false, 1},
+		{[]byte("0.10000000000000000001"), []byte("0.1"), mergedNumber, false, 1},
+		{[]byte("-1e3"), []byte("-999"), mergedNumber, false, -1},
+		{[]byte("b"), []byte("a"), mergedBytes, false, 1},
+		{[]byte("2023-09-06 18:37:46"), []byte("2023-09-06 18:37:46.5"), mergedTime, false, -1},
+		{[]byte("2023-09-06T18:37:46.5Z"), []byte("2023-09-06T18:37:46Z"), mergedTime, false, 1},
+		{[]byte("2023-09-06T18:37:46+08:00"), []byte("2023-09-06T11:37:46Z"), mergedTime, false, -1},
+		{[]byte("2023-09-06T18:37:46+08:00"), []byte("2023-09-06T10:37:46Z"), mergedTime, false, 0},
+		{[]byte("2023-09-06"), []byte("2023-09-05"), mergedTime, false, 1},
+		{nil, []byte("a"), mergedBytes, false, -1},
+		{nil, []byte("a"), mergedBytes, true, 1},
+		{[]byte("a"), nil, mergedBytes, true, -1},
+		{nil, nil, mergedNumber, true, 0},
+	} {
+		if result, err := compareMergedValues(item.a, item.b, item.kind, item.nullsLast); result != item.expected || err != nil {
+			t.Error(string(item.a), string(item.b), item.kind, item.nullsLast, result, err)
+		}
+	}
+
+	if _, err := compareMergedValues([]byte("2"), []byte("a"), mergedNumber, false); err == nil {
+		t.Error("should get error here")
+	}
+	if _, err := compareMergedValues([]byte("2023-09-06"), []byte("a"), mergedTime, false); err == nil {
+		t.Error("should get error here")
+	}
+}
+
+func TestGetMergedColumnKind(t *testing.T) {
+	for _, item := range []struct {
+		scanType         reflect.Type
+		databaseTypeName string
+		kind             mergedColumnKind
+		refused          bool
+	}{
+		{reflect.TypeOf(int64(0)), "BIGINT", mergedNumber, false},
+		{reflect.TypeOf(sql.NullFloat64{}), "DOUBLE", mergedNumber, false},
+		{reflect.TypeOf(sql.RawBytes{}), "DECIMAL", mergedNumber, false},
+		{reflect.TypeOf(sql.RawBytes{}), "UNSIGNED INT", mergedNumber, false},
+		{reflect.TypeOf(time.Time{}), "", mergedTime, false},
+		{reflect.TypeOf(sql.NullTime{}), "DATETIME", mergedTime, false},
+		{reflect.TypeOf(sql.RawBytes{}), "VARBINARY", mergedBytes, false},
+		{reflect.TypeOf(""), "TIMESTAMPTZ", mergedTime, false},
+		{reflect.TypeOf(sql.NullBool{}), "BOOL", mergedBytes, false},
+		{reflect.TypeOf(sql.RawBytes{}), "VARCHAR", mergedBytes, true},
+		{reflect.TypeOf(""), "TEXT", mergedBytes, true},
+		{nil, "", mergedBytes, true},
+	} {
+		kind, err := getMergedColumnKind(item.scanType, item.databaseTypeName)
+		if kind != item.kind || (err != nil) != item.refused {
+			t.Error(item.scanType, item.databaseTypeName, kind, err)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package sqlingo
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"sync"
+)
+
+// StatementCacheStats is the statistics of the prepared statement cache.
+type StatementCacheStats struct {
+	Hits   uint64
+	Misses uint64
+	Size   int
+}
+
+type stmtCacheEntry struct {
+	sql  string
+	stmt *sql.Stmt
+}
+
+// stmtCache is an LRU cache of prepared statements keyed by the SQL string.
+// It is resized in place, as it is shared by the database and its transactions which may be running concurrently.
+type stmtCache struct {
+	mutex    sync.Mutex
+	capacity int
+	list     *list.List
+	elements map[string]*list.Element
+	hits     uint64
+	misses   uint64
+}
+
+// newStmtCache returns a cache which is disabled until resized.
+func newStmtCache() *stmtCache {
+	return &stmtCache{
+		list:     list.New(),
+		elements: make(map[string]*list.Element),
+	}
+}
+
+// isEnabled tells whether the statements should be prepared through the cache.
+func (c *stmtCache) isEnabled() bool {
+	if c == nil {
+		return false
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.capacity > 0
+}
+
+// resize sets the capacity, evicting the least recently used statements, and resets the counters.
+// Zero capacity disables the cache.
+func (c *stmtCache) resize(capacity int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.capacity = capacity
+	c.hits = 0
+	c.misses = 0
+	for c.list.Len() > c.capacity {
+		c.removeElement(c.list.Back())
+	}
+}
+
+// get returns the cached statement of sqlString, or prepares it.
+// cached is false if the cache is disabled meanwhile, in which case the statement should be closed by the caller.
+func (c *stmtCache) get(ctx context.Context, db *sql.DB, sqlString string) (stmt *sql.Stmt, cached bool, err error) {
+	c.mutex.Lock()
+	if element, ok := c.elements[sqlString]; ok {
+		c.list.MoveToFront(element)
+		c.hits++
+		c.mutex.Unlock()
+		return element.Value.(*stmtCacheEntry).stmt, true, nil
+	}
+	c.misses++
+	c.mutex.Unlock()
+
+	// prepare without holding the lock, another goroutine may prepare the same statement concurrently
+	stmt, err = db.PrepareContext(ctx, sqlString)
+	if err != nil {
+		return nil, false, err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if element, ok := c.elements[sqlString]; ok {
+		_ = stmt.Close()
+		c.list.MoveToFront(element)
+		return element.Value.(*stmtCacheEntry).stmt, true, nil
+	}
+	if c.capacity == 0 {
+		return stmt, false, nil
+	}
+	c.elements[sqlString] = c.list.PushFront(&stmtCacheEntry{sql: sqlString, stmt: stmt})
+	for c.list.Len() > c.capacity {
+		c.removeElement(c.list.Back())
+	}
+	return stmt, true, nil
+}
+
+// remove evicts the statement if it is still the cached one for sqlString.
+func (c *stmtCache) remove(sqlString string, stmt *sql.Stmt) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if element, ok := c.elements[sqlString]; ok && element.Value.(*stmtCacheEntry).stmt == stmt {
+		c.removeElement(element)
+	}
+}
+
+func (c *stmtCache) removeElement(element *list.Element) {
+	entry := c.list.Remove(element).(*stmtCacheEntry)
+	delete(c.elements, entry.sql)
+	_ = entry.stmt.Close()
+}
+
+func (c *stmtCache) stats() StatementCacheStats {
+	if c == nil {
+		return StatementCacheStats{}
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return StatementCacheStats{
+		Hits:   c.hits,
+		Misses: c.misses,
+		Size:   c.list.Len(),
+	}
+}
+
+func isConnectionError(err error) bool {
+	return errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, sql.ErrConnDone) ||
+		strings.Contains(err.Error(), "statement is closed")
+}
+
+// withStmt calls f with the cached prepared statement of sqlString, which is bound to the transaction if any.
+// The statement is evicted and prepared again once if it fails because of a broken connection.
+func (c *stmtCache) withStmt(ctx context.Context, db *sql.DB, txOrDB txOrDB, sqlString string, f func(stmt *sql.Stmt) error) error {
+	tx, isTx := txOrDB.(*sql.Tx)
+	for retry := false; ; retry = true {
+		stmt, cached, err := c.get(ctx, db, sqlString)
+		if err != nil {
+			return err
+		}
+		if isTx {
+			// statements bound to a transaction are closed when the transaction ends
+			err = f(tx.StmtContext(ctx, stmt))
+		} else {
+			err = f(stmt)
+		}
+		if !cached {
+			// the open rows keep the statement until they are closed
+			_ = stmt.Close()
+			return err
+		}
+		if err == nil || !isConnectionError(err) {
+			return err
+		}
+		c.remove(sqlString, stmt)
+		if retry || isTx {
+			return err
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package sqlingo
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStatementCache(t *testing.T) {
+	db := newParameterizedMockDatabase(dialectMySQL)
+	db.SetStatementCacheSize(2)
+	defer db.SetStatementCacheSize(0)
+
+	for i := 0; i < 3; i++ {
+		if _, err := db.Select(field1).From(Table1).Where(field1.Equals(i)).FetchFirst(); err != nil {
+			t.Error(err)
+		}
+		assertLastArgs(t, int64(i))
+	}
+	if stats := db.GetStatementCacheStats(); stats.Hits != 2 || stats.Misses != 1 || stats.Size != 1 {
+		t.Error(stats)
+	}
+
+	_, _ = db.Update(Table1).Set(field1, 1).Where(field2.Equals(2)).Execute()
+	_, _ = db.DeleteFrom(Table1).Where(field2.Equals(2)).Execute()
+	if stats := db.GetStatementCacheStats(); stats.Misses != 3 || stats.Size != 2 {
+		t.Error(stats)
+	}
+
+	// a closed statement is prepared again
+	cache := db.(*database).stmtCache
+	_ = cache.list.Front().Value.(*stmtCacheEntry).stmt.Close()
+	if _, err := db.DeleteFrom(Table1).Where(field2.Equals(2)).Execute(); err != nil {
+		t.Error(err)
+	}
+	if stats := db.GetStatementCacheStats(); stats.Misses != 4 || stats.Size != 2 {
+		t.Error(stats)
+	}
+
+	err := db.BeginTx(nil, nil, func(tx Transaction) error {
+		_, err := tx.DeleteFrom(Table1).Where(field2.Equals(3)).Execute()
+		return err
+	})
+	if err != nil {
+		t.Error(err)
+	}
+	assertLastArgs(t, int64(3))
+	if stats := db.GetStatementCacheStats(); stats.Hits != 4 {
+		t.Error(stats)
+	}
+
+	db.SetStatementCacheSize(0)
+	if stats := db.GetStatementCacheStats(); stats != (StatementCacheStats{}) {
+		t.Error(stats)
+	}
+}
+
+func TestStatementCacheWithCallerInfo(t *testing.T) {
+	db := newParameterizedMockDatabase(dialectMySQL)
+	db.EnableCallerInfo(true)
+	db.SetStatementCacheSize(2)
+	defer db.SetStatementCacheSize(0)
+
+	_, _ = db.DeleteFrom(Table1).Where(field2.Equals(1)).Execute()
+	assertLastSql(t, "DELETE FROM `table1` WHERE `field2` = ?")
+	_, _ = db.DeleteFrom(Table1).Where(field2.Equals(2)).Execute()
+	if stats := db.GetStatementCacheStats(); stats.Hits != 1 || stats.Misses != 1 {
+		t.Error(stats)
+	}
+
+	// the statements are executed directly once the cache is disabled
+	db.SetStatementCacheSize(0)
+	_, _ = db.DeleteFrom(Table1).Where(field2.Equals(3)).Execute()
+	if lastSql := sharedMockConn.lastSql; !strings.HasPrefix(lastSql, "/* ") {
+		t.Error(lastSql)
+	}
+}
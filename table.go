@@ -24,11 +24,11 @@ func (t table) GetName() string {
 }
 
 func (t table) GetSQL(scope scope) string {
-	dialect := dialectUnknown
-	if scope.Database != nil {
-		dialect = scope.Database.dialect
+	dialect := scope.getDialect()
+	if index := getBuiltinDialectIndex(dialect); index >= 0 {
+		return t.sqlDialects[index]
 	}
-	return t.sqlDialects[dialect]
+	return dialect.QuoteIdentifier(t.name)
 }
 
 func (t table) getOperatorPriority() int {
@@ -50,7 +50,7 @@ func (t derivedTable) GetName() string {
 }
 
 func (t derivedTable) GetSQL(scope scope) string {
-	sql, _ := t.selectStatus.GetSQL()
+	sql, _ := t.selectStatus.buildSQL(scope.args)
 	return "(" + sql + ") AS " + t.name
 }
 
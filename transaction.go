@@ -3,24 +3,51 @@ package sqlingo
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
 )
 
 // Transaction is the interface of a transaction with underlying sql.Tx object.
 // It provides methods to execute DDL and TCL operations.
 type Transaction interface {
-	GetDB() *sql.DB
-	GetTx() *sql.Tx
-	Query(sql string) (Cursor, error)
-	Execute(sql string) (sql.Result, error)
+	Executor
 
-	Select(fields ...interface{}) selectWithFields
-	SelectDistinct(fields ...interface{}) selectWithFields
-	SelectFrom(tables ...Table) selectWithTables
-	InsertInto(table Table) insertWithTable
-	Update(table Table) updateWithSet
-	DeleteFrom(table Table) deleteWithTable
+	GetTx() *sql.Tx
+	// GetInterceptor returns the interceptor function inherited from the database.
+	GetInterceptor() InterceptorFunc
+	// SetInterceptor sets an interceptor function for the statements executed by the transaction object.
+	SetInterceptor(interceptor InterceptorFunc)
+	// Savepoint runs the function f in a savepoint of the transaction.
+	// If f returns an error or panics, only the changes made by f are rolled back.
+	Savepoint(ctx context.Context, f func(tx Transaction) error) error
+	// AfterCommit registers a function to be called after the transaction is committed,
+	// the functions are called in the order of registration, and discarded if the transaction is rolled back or retried.
+	// The ones registered in a savepoint are discarded as well if the savepoint is rolled back.
+	AfterCommit(f func(ctx context.Context))
+	// AfterRollback registers a function to be called with the error after the transaction is rolled back,
+	// including each attempt of a retried transaction.
+	AfterRollback(f func(ctx context.Context, err error))
 }
 
+// TxPropagation decides how EnsureTxWithPropagation runs the function if ctx already contains a transaction.
+type TxPropagation int
+
+const (
+	// TxRequired reuses the transaction in ctx, the same as EnsureTx.
+	TxRequired TxPropagation = iota
+	// TxRequiresNew begins a new transaction independent of the one in ctx,
+	// which is committed or rolled back before returning, regardless of the outcome of the outer one.
+	// It takes another connection from the pool while the outer transaction is holding one.
+	TxRequiresNew
+	// TxNested runs the function in a savepoint of the transaction in ctx, the same as EnsureNestedTx.
+	TxNested
+)
+
+var savepointSequence int64
+
 type txContextKey struct{}
 
 // WithTransaction stores the transaction in the context.
@@ -41,20 +68,45 @@ func (d *database) BeginTx(ctx context.Context, opts *sql.TxOptions, f func(tx T
 	if ctx == nil {
 		ctx = context.Background()
 	}
+	if d.shards != nil {
+		shard, err := d.getShard(WithoutTransaction(ctx))
+		if err != nil {
+			return err
+		}
+		return shard.BeginTx(ctx, opts, f)
+	}
+	policy := d.getTxRetryPolicy(ctx)
+	for attempt := 1; ; attempt++ {
+		err := d.beginTxOnce(ctx, opts, f)
+		if err == nil || !policy.shouldRetry(ctx, attempt, err) {
+			return err
+		}
+	}
+}
+
+func (d *database) beginTxOnce(ctx context.Context, opts *sql.TxOptions, f func(tx Transaction) error) (err error) {
 	tx, err := d.db.BeginTx(ctx, opts)
 	if err != nil {
 		return err
 	}
+	callbacks := &txCallbacks{}
 	isCommitted := false
 	defer func() {
-		if !isCommitted {
-			_ = tx.Rollback()
+		if isCommitted {
+			return
 		}
+		_ = tx.Rollback()
+		if r := recover(); r != nil {
+			callbacks.runAfterRollback(ctx, fmt.Errorf("panic: %v", r))
+			panic(r)
+		}
+		callbacks.runAfterRollback(ctx, err)
 	}()
 
 	if f != nil {
 		db := *d
 		db.tx = tx
+		db.txCallbacks = callbacks
 		err = f(&db)
 		if err != nil {
 			return err
@@ -66,12 +118,61 @@ func (d *database) BeginTx(ctx context.Context, opts *sql.TxOptions, f func(tx T
 		return err
 	}
 	isCommitted = true
+	callbacks.runAfterCommit(ctx)
 	return nil
 }
 
+// txCallbacks holds the callbacks registered in a transaction, which are discarded with the transaction if it's retried.
+type txCallbacks struct {
+	mutex         sync.Mutex
+	afterCommit   []func(ctx context.Context)
+	afterRollback []func(ctx context.Context, err error)
+}
+
+func (c *txCallbacks) runAfterCommit(ctx context.Context) {
+	c.mutex.Lock()
+	callbacks := c.afterCommit
+	c.mutex.Unlock()
+	for _, callback := range callbacks {
+		callback(ctx)
+	}
+}
+
+func (c *txCallbacks) runAfterRollback(ctx context.Context, err error) {
+	c.mutex.Lock()
+	callbacks := c.afterRollback
+	c.mutex.Unlock()
+	for _, callback := range callbacks {
+		callback(ctx, err)
+	}
+}
+
+func (d *database) AfterCommit(f func(ctx context.Context)) {
+	d.txCallbacks.mutex.Lock()
+	defer d.txCallbacks.mutex.Unlock()
+	d.txCallbacks.afterCommit = append(d.txCallbacks.afterCommit, f)
+}
+
+func (d *database) AfterRollback(f func(ctx context.Context, err error)) {
+	d.txCallbacks.mutex.Lock()
+	defer d.txCallbacks.mutex.Unlock()
+	d.txCallbacks.afterRollback = append(d.txCallbacks.afterRollback, f)
+}
+
+// TransactionFromContext returns the transaction stored in the context by EnsureTx or WithTransaction,
+// so that callbacks can be registered with AfterCommit or AfterRollback.
+func TransactionFromContext(ctx context.Context) (Transaction, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	tx, ok := ctx.Value(txContextKey{}).(Transaction)
+	return tx, ok
+}
+
 // EnsureTx ensures the function f runs within a transaction.
 // If ctx already contains a transaction started by a previous EnsureTx call, it reuses that transaction.
 // Otherwise, it begins a new transaction and stores it in the context.
+// Only the new transaction is retried on failure, as the reused one is retried by whoever began it.
 func (d *database) EnsureTx(ctx context.Context, opts *sql.TxOptions, f func(ctx context.Context) error) error {
 	if ctx == nil {
 		ctx = context.Background()
@@ -86,3 +187,89 @@ func (d *database) EnsureTx(ctx context.Context, opts *sql.TxOptions, f func(ctx
 		return f(WithTransaction(ctx, tx))
 	})
 }
+
+// EnsureNestedTx is similar to EnsureTx, but if ctx already contains a transaction,
+// it runs the function f in a savepoint of that transaction, so that the failure of f only rolls back the changes made by f.
+// To run f in a new independent transaction, use EnsureTxWithPropagation with TxRequiresNew instead.
+func (d *database) EnsureNestedTx(ctx context.Context, opts *sql.TxOptions, f func(ctx context.Context) error) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if tx, ok := ctx.Value(txContextKey{}).(Transaction); ok {
+		return tx.Savepoint(ctx, func(tx Transaction) error {
+			return f(ctx)
+		})
+	}
+	if d.tx != nil {
+		return d.Savepoint(ctx, func(tx Transaction) error {
+			return f(WithTransaction(ctx, tx))
+		})
+	}
+	return d.EnsureTx(ctx, opts, f)
+}
+
+// EnsureTxWithPropagation ensures the function f runs within a transaction.
+// If there is no transaction in ctx, it begins a new one as EnsureTx does,
+// otherwise the transaction for f is decided by the propagation.
+func (d *database) EnsureTxWithPropagation(ctx context.Context, opts *sql.TxOptions, propagation TxPropagation, f func(ctx context.Context) error) error {
+	switch propagation {
+	case TxRequired:
+		return d.EnsureTx(ctx, opts, f)
+	case TxRequiresNew:
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		return d.BeginTx(WithoutTransaction(ctx), opts, func(tx Transaction) error {
+			return f(WithTransaction(ctx, tx))
+		})
+	case TxNested:
+		return d.EnsureNestedTx(ctx, opts, f)
+	default:
+		return fmt.Errorf("unknown transaction propagation %d", propagation)
+	}
+}
+
+func (d *database) Savepoint(ctx context.Context, f func(tx Transaction) error) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if d.tx == nil {
+		return errors.New("savepoint should be created in a transaction")
+	}
+	name := "sqlingo_savepoint_" + strconv.FormatInt(atomic.AddInt64(&savepointSequence, 1), 10)
+	savepointSql, rollbackSql, releaseSql := d.dialect.Savepoint(name)
+	if err := d.executeUnprepared(ctx, savepointSql); err != nil {
+		return err
+	}
+	d.txCallbacks.mutex.Lock()
+	afterCommitCount := len(d.txCallbacks.afterCommit)
+	d.txCallbacks.mutex.Unlock()
+	isReleased := false
+	defer func() {
+		if !isReleased {
+			_ = d.executeUnprepared(ctx, rollbackSql)
+			d.txCallbacks.mutex.Lock()
+			d.txCallbacks.afterCommit = d.txCallbacks.afterCommit[:afterCommitCount]
+			d.txCallbacks.mutex.Unlock()
+		}
+	}()
+
+	if err := f(d); err != nil {
+		return err
+	}
+	if releaseSql != "" {
+		if err := d.executeUnprepared(ctx, releaseSql); err != nil {
+			return err
+		}
+	}
+	isReleased = true
+	return nil
+}
+
+// executeUnprepared executes the statement without the statement cache, such as the savepoint statements.
+func (d *database) executeUnprepared(ctx context.Context, sqlString string) error {
+	return d.intercept(ctx, sqlString, func(ctx context.Context) error {
+		_, err := d.getTxOrDB(ctx).ExecContext(ctx, sqlString)
+		return err
+	})
+}
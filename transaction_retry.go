@@ -0,0 +1,139 @@
+package sqlingo
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"reflect"
+	"time"
+)
+
+// TxRetryPolicy is the policy to re-run the whole transaction function of BeginTx or EnsureTx,
+// if the transaction fails with a transient error, such as a deadlock or a serialization failure.
+type TxRetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts including the first one, no retries if it's less than 2.
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry, which doubles for each subsequent retry.
+	InitialBackoff time.Duration
+	// MaxBackoff limits the delay before each retry if it's not zero.
+	MaxBackoff time.Duration
+	// IsRetryable tells whether the error is retryable, IsRetryableTxError is used if it's nil.
+	IsRetryable func(err error) bool
+	// OnRetry is called before each retry, with the number of the failed attempt starting from 1.
+	OnRetry func(ctx context.Context, attempt int, err error, backoff time.Duration)
+}
+
+type txRetryPolicyContextKey struct{}
+
+// WithTxRetryPolicy returns a context with the retry policy for the transactions begun with it,
+// which overrides the one set by SetTxRetryPolicy. A nil policy disables retries.
+func WithTxRetryPolicy(ctx context.Context, policy *TxRetryPolicy) context.Context {
+	return context.WithValue(ctx, txRetryPolicyContextKey{}, policy)
+}
+
+func (d *database) SetTxRetryPolicy(policy *TxRetryPolicy) {
+	d.txRetryPolicy = policy
+}
+
+func (d *database) getTxRetryPolicy(ctx context.Context) *TxRetryPolicy {
+	if policy, ok := ctx.Value(txRetryPolicyContextKey{}).(*TxRetryPolicy); ok {
+		return policy
+	}
+	return d.txRetryPolicy
+}
+
+// backoff returns the delay before the retry after the attempt, with jitter between half and full of the exponential backoff.
+func (p *TxRetryPolicy) backoff(attempt int) time.Duration {
+	backoff := p.InitialBackoff
+	for i := 1; i < attempt && (p.MaxBackoff == 0 || backoff < p.MaxBackoff); i++ {
+		backoff *= 2
+	}
+	if p.MaxBackoff != 0 && backoff > p.MaxBackoff {
+		backoff = p.MaxBackoff
+	}
+	if backoff <= 1 {
+		return backoff
+	}
+	return backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+}
+
+// shouldRetry tells whether to retry after the failed attempt, and waits for the backoff if so.
+func (p *TxRetryPolicy) shouldRetry(ctx context.Context, attempt int, err error) bool {
+	if p == nil || attempt >= p.MaxAttempts {
+		return false
+	}
+	isRetryable := p.IsRetryable
+	if isRetryable == nil {
+		isRetryable = IsRetryableTxError
+	}
+	if !isRetryable(err) {
+		return false
+	}
+
+	backoff := p.backoff(attempt)
+	if p.OnRetry != nil {
+		p.OnRetry(ctx, attempt, err, backoff)
+	}
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// IsRetryableTxError tells whether the transaction failed with an error worth retrying, which is one of
+// MySQL deadlock (1213) and lock wait timeout (1205), PostgreSQL serialization failure (40001) and deadlock (40P01),
+// or SQLite busy (SQLITE_BUSY). The errors of the common drivers are recognized without importing them.
+func IsRetryableTxError(err error) bool {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if sqlStateError, ok := err.(interface{ SQLState() string }); ok {
+			if isRetryableSQLState(sqlStateError.SQLState()) {
+				return true
+			}
+			continue
+		}
+		if codeError, ok := err.(interface{ Code() int }); ok {
+			// modernc.org/sqlite, the extended result codes of SQLITE_BUSY share the lower byte
+			if codeError.Code()&0xff == 5 {
+				return true
+			}
+			continue
+		}
+
+		value := reflect.Indirect(reflect.ValueOf(err))
+		if value.Kind() != reflect.Struct {
+			continue
+		}
+		if number := value.FieldByName("Number"); number.IsValid() {
+			// github.com/go-sql-driver/mysql
+			switch number.Kind() {
+			case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+				if n := number.Uint(); n == 1213 || n == 1205 {
+					return true
+				}
+			}
+		}
+		if code := value.FieldByName("Code"); code.IsValid() {
+			switch code.Kind() {
+			case reflect.String:
+				// github.com/lib/pq
+				if isRetryableSQLState(code.String()) {
+					return true
+				}
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				// github.com/mattn/go-sqlite3
+				if code.Int() == 5 {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func isRetryableSQLState(sqlState string) bool {
+	return sqlState == "40001" || sqlState == "40P01"
+}
@@ -0,0 +1,182 @@
+package sqlingo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+type mockMySQLError struct {
+	Number  uint16
+	Message string
+}
+
+func (e *mockMySQLError) Error() string {
+	return e.Message
+}
+
+type mockPQErrorCode string
+
+type mockPQError struct {
+	Code mockPQErrorCode
+}
+
+func (e *mockPQError) Error() string {
+	return string(e.Code)
+}
+
+type mockSQLiteErrNo int
+
+type mockSQLiteError struct {
+	Code mockSQLiteErrNo
+}
+
+func (e mockSQLiteError) Error() string {
+	return "database is locked"
+}
+
+type mockPgError struct{}
+
+func (e *mockPgError) Error() string {
+	return "deadlock detected"
+}
+
+func (e *mockPgError) SQLState() string {
+	return "40P01"
+}
+
+func TestIsRetryableTxError(t *testing.T) {
+	for _, item := range []struct {
+		err      error
+		expected bool
+	}{
+		{nil, false},
+		{errors.New("error"), false},
+		{&mockMySQLError{Number: 1213}, true},
+		{&mockMySQLError{Number: 1205}, true},
+		{&mockMySQLError{Number: 1062}, false},
+		{&mockPQError{Code: "40001"}, true},
+		{&mockPQError{Code: "23505"}, false},
+		{mockSQLiteError{Code: 5}, true},
+		{mockSQLiteError{Code: 19}, false},
+		{&mockPgError{}, true},
+		{fmt.Errorf("wrapped: %w", &mockMySQLError{Number: 1213}), true},
+	} {
+		if IsRetryableTxError(item.err) != item.expected {
+			t.Error(item.err, item.expected)
+		}
+	}
+}
+
+func TestTxRetryPolicyBackoff(t *testing.T) {
+	policy := &TxRetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond}
+	for attempt, expected := range []time.Duration{100, 200, 300, 300} {
+		expected *= time.Millisecond
+		if backoff := policy.backoff(attempt + 1); backoff < expected/2 || backoff > expected {
+			t.Error(attempt+1, backoff)
+		}
+	}
+	if backoff := (&TxRetryPolicy{}).backoff(3); backoff != 0 {
+		t.Error(backoff)
+	}
+}
+
+func TestBeginTxRetry(t *testing.T) {
+	db := newMockDatabase()
+	var retries []int
+	db.SetTxRetryPolicy(&TxRetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Microsecond,
+		OnRetry: func(ctx context.Context, attempt int, err error, backoff time.Duration) {
+			retries = append(retries, attempt)
+		},
+	})
+
+	attempts := 0
+	err := db.BeginTx(context.Background(), nil, func(tx Transaction) error {
+		attempts++
+		if attempts < 3 {
+			return &mockMySQLError{Number: 1213}
+		}
+		return nil
+	})
+	if err != nil || attempts != 3 || fmt.Sprint(retries) != "[1 2]" {
+		t.Error(err, attempts, retries)
+	}
+	if !sharedMockConn.mockTx.isCommitted {
+		t.Error("should be committed")
+	}
+
+	// gives up after the maximum attempts
+	attempts = 0
+	retries = nil
+	err = db.EnsureTx(context.Background(), nil, func(ctx context.Context) error {
+		attempts++
+		return &mockMySQLError{Number: 1213}
+	})
+	if err == nil || attempts != 3 || len(retries) != 2 {
+		t.Error(err, attempts, retries)
+	}
+
+	// not retryable
+	attempts = 0
+	err = db.BeginTx(context.Background(), nil, func(tx Transaction) error {
+		attempts++
+		return errors.New("error")
+	})
+	if err == nil || attempts != 1 {
+		t.Error(err, attempts)
+	}
+
+	// disabled by the context
+	attempts = 0
+	err = db.BeginTx(WithTxRetryPolicy(context.Background(), nil), nil, func(tx Transaction) error {
+		attempts++
+		return &mockMySQLError{Number: 1213}
+	})
+	if err == nil || attempts != 1 {
+		t.Error(err, attempts)
+	}
+
+	// the reused transaction is not retried by the inner EnsureTx
+	attempts = 0
+	innerAttempts := 0
+	_ = db.EnsureTx(context.Background(), nil, func(ctx context.Context) error {
+		attempts++
+		return db.EnsureTx(ctx, nil, func(ctx context.Context) error {
+			innerAttempts++
+			return &mockMySQLError{Number: 1213}
+		})
+	})
+	if attempts != 3 || innerAttempts != 3 {
+		t.Error(attempts, innerAttempts)
+	}
+
+	// retried on commit failure
+	attempts = 0
+	err = db.BeginTx(context.Background(), nil, func(tx Transaction) error {
+		attempts++
+		if attempts == 1 {
+			sharedMockConn.mockTx.commitError = &mockPQError{Code: "40001"}
+		}
+		return nil
+	})
+	if err != nil || attempts != 2 {
+		t.Error(err, attempts)
+	}
+
+	// stops waiting if the context is done
+	ctx, cancel := context.WithCancel(context.Background())
+	db.SetTxRetryPolicy(&TxRetryPolicy{MaxAttempts: 3, InitialBackoff: time.Hour})
+	attempts = 0
+	err = db.BeginTx(ctx, nil, func(tx Transaction) error {
+		attempts++
+		cancel()
+		return &mockMySQLError{Number: 1213}
+	})
+	if err == nil || attempts != 1 {
+		t.Error(err, attempts)
+	}
+}
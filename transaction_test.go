@@ -3,6 +3,9 @@ package sqlingo
 import (
 	"context"
 	"errors"
+	"regexp"
+	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -82,6 +85,54 @@ func TestTransaction(t *testing.T) {
 	}
 }
 
+func TestExecutor(t *testing.T) {
+	db := newMockDatabase()
+	var sqls []string
+	db.SetInterceptor(func(ctx context.Context, sql string, invoker InvokerFunc) error {
+		sqls = append(sqls, "db: "+sql)
+		return invoker(ctx, sql)
+	})
+
+	useExecutor := func(executor Executor) {
+		if _, err := executor.ReplaceInto(Table1).Fields(field1).Values(1).Execute(); err != nil {
+			t.Error(err)
+		}
+		if _, err := executor.ExecuteContext(context.Background(), "<dummy>"); err != nil {
+			t.Error(err)
+		}
+		if err := executor.EnsureTx(context.Background(), nil, func(ctx context.Context) error {
+			_, err := executor.DeleteFrom(Table1).Where(field1.Equals(1)).WithContext(ctx).Execute()
+			return err
+		}); err != nil {
+			t.Error(err)
+		}
+	}
+
+	useExecutor(db)
+	if len(sqls) != 3 {
+		t.Error(sqls)
+	}
+
+	sqls = nil
+	if err := db.BeginTx(context.Background(), nil, func(tx Transaction) error {
+		inherited := tx.GetInterceptor()
+		tx.SetInterceptor(func(ctx context.Context, sql string, invoker InvokerFunc) error {
+			sqls = append(sqls, "tx")
+			return inherited(ctx, sql, invoker)
+		})
+		useExecutor(tx)
+		return nil
+	}); err != nil {
+		t.Error(err)
+	}
+	if len(sqls) != 6 || sqls[0] != "tx" || sqls[1] != "db: REPLACE INTO `table1` (`field1`) VALUES (1)" {
+		t.Error(sqls)
+	}
+	if db.GetInterceptor() == nil {
+		t.Error("the interceptor of database should not be changed")
+	}
+}
+
 func TestWithTransaction(t *testing.T) {
 	ctx := context.Background()
 
@@ -207,6 +258,290 @@ func TestEnsureTx(t *testing.T) {
 	sharedMockConn.beginTxError = nil
 }
 
+func TestSavepoint(t *testing.T) {
+	db := newMockDatabase()
+	var sqls []string
+	db.SetInterceptor(func(ctx context.Context, sql string, invoker InvokerFunc) error {
+		sqls = append(sqls, regexp.MustCompile(`sqlingo_savepoint_\d+`).ReplaceAllString(sql, "sp"))
+		return invoker(ctx, sql)
+	})
+
+	err := db.BeginTx(context.Background(), nil, func(tx Transaction) error {
+		if err := tx.Savepoint(context.Background(), func(tx Transaction) error {
+			_, err := tx.Execute("<ok>")
+			return err
+		}); err != nil {
+			t.Error(err)
+		}
+		if err := tx.Savepoint(nil, func(tx Transaction) error {
+			_, _ = tx.Execute("<failed>")
+			return errors.New("error")
+		}); err == nil {
+			t.Error("should get error here")
+		}
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("should panic")
+				}
+			}()
+			_ = tx.Savepoint(context.Background(), func(tx Transaction) error {
+				panic("panic")
+			})
+		}()
+		return nil
+	})
+	if err != nil {
+		t.Error(err)
+	}
+	assertEqual(t, strings.Join(sqls, "; "), "SAVEPOINT sp; <ok>; RELEASE SAVEPOINT sp; "+
+		"SAVEPOINT sp; <failed>; ROLLBACK TO SAVEPOINT sp; "+
+		"SAVEPOINT sp; ROLLBACK TO SAVEPOINT sp")
+	if !sharedMockConn.mockTx.isCommitted {
+		t.Error("the outer transaction should be committed")
+	}
+
+	sqls = nil
+	db.(*database).dialect = dialectMSSQL
+	_ = db.BeginTx(context.Background(), nil, func(tx Transaction) error {
+		_ = tx.Savepoint(context.Background(), func(tx Transaction) error {
+			return nil
+		})
+		_ = tx.Savepoint(context.Background(), func(tx Transaction) error {
+			return errors.New("error")
+		})
+		return nil
+	})
+	assertEqual(t, strings.Join(sqls, "; "), "SAVE TRANSACTION sp; SAVE TRANSACTION sp; ROLLBACK TRANSACTION sp")
+
+	if err := db.(*database).Savepoint(context.Background(), func(tx Transaction) error {
+		return nil
+	}); err == nil {
+		t.Error("should get error here")
+	}
+}
+
+func TestEnsureNestedTx(t *testing.T) {
+	db := newMockDatabase()
+	var sqls []string
+	db.SetInterceptor(func(ctx context.Context, sql string, invoker InvokerFunc) error {
+		sqls = append(sqls, regexp.MustCompile(`sqlingo_savepoint_\d+`).ReplaceAllString(sql, "sp"))
+		return invoker(ctx, sql)
+	})
+
+	// begins a new transaction
+	err := db.EnsureNestedTx(nil, nil, func(ctx context.Context) error {
+		outerTx := ctx.Value(txContextKey{}).(Transaction)
+
+		// creates a savepoint in the transaction of ctx
+		err := db.EnsureNestedTx(ctx, nil, func(innerCtx context.Context) error {
+			innerTx := innerCtx.Value(txContextKey{}).(Transaction)
+			if innerTx.GetTx() != outerTx.GetTx() {
+				t.Error("should be in the same transaction")
+			}
+			_, _ = db.DeleteFrom(Table1).Where(field1.Equals(1)).WithContext(innerCtx).Execute()
+			return errors.New("error")
+		})
+		if err == nil {
+			t.Error("should get error here")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Error(err)
+	}
+	assertEqual(t, strings.Join(sqls, "; "), "SAVEPOINT sp; DELETE FROM `table1` WHERE `field1` = 1; ROLLBACK TO SAVEPOINT sp")
+	if !sharedMockConn.mockTx.isCommitted {
+		t.Error("should be committed")
+	}
+
+	// creates a savepoint in the transaction object
+	sqls = nil
+	_ = db.BeginTx(context.Background(), nil, func(tx Transaction) error {
+		return tx.EnsureNestedTx(context.Background(), nil, func(ctx context.Context) error {
+			if ctx.Value(txContextKey{}).(Transaction).GetTx() != tx.GetTx() {
+				t.Error("should be in the same transaction")
+			}
+			return nil
+		})
+	})
+	assertEqual(t, strings.Join(sqls, "; "), "SAVEPOINT sp; RELEASE SAVEPOINT sp")
+}
+
+func TestEnsureTxWithPropagation(t *testing.T) {
+	db := newMockDatabase()
+
+	err := db.EnsureTxWithPropagation(nil, nil, TxRequired, func(ctx context.Context) error {
+		outerTx := ctx.Value(txContextKey{}).(Transaction)
+
+		// TxRequired reuses the transaction
+		_ = db.EnsureTxWithPropagation(ctx, nil, TxRequired, func(innerCtx context.Context) error {
+			if innerCtx.Value(txContextKey{}).(Transaction).GetTx() != outerTx.GetTx() {
+				t.Error("should be in the same transaction")
+			}
+			return nil
+		})
+
+		// TxRequiresNew begins an independent transaction, and rolls it back alone
+		err := db.EnsureTxWithPropagation(ctx, nil, TxRequiresNew, func(innerCtx context.Context) error {
+			innerTx := innerCtx.Value(txContextKey{}).(Transaction)
+			if innerTx.GetTx() == outerTx.GetTx() {
+				t.Error("should be in a new transaction")
+			}
+			return errors.New("error")
+		})
+		if err == nil {
+			t.Error("should get error here")
+		}
+		if !sharedMockConn.mockTx.isRolledBack {
+			t.Error("should be rolled back")
+		}
+
+		// TxRequiresNew commits the new transaction before returning
+		if err := outerTx.EnsureTxWithPropagation(ctx, nil, TxRequiresNew, func(innerCtx context.Context) error {
+			if innerCtx.Value(txContextKey{}).(Transaction).GetTx() == outerTx.GetTx() {
+				t.Error("should be in a new transaction")
+			}
+			return nil
+		}); err != nil {
+			t.Error(err)
+		}
+		if !sharedMockConn.mockTx.isCommitted {
+			t.Error("should be committed")
+		}
+
+		// TxNested creates a savepoint
+		return db.EnsureTxWithPropagation(ctx, nil, TxNested, func(innerCtx context.Context) error {
+			if innerCtx.Value(txContextKey{}).(Transaction).GetTx() != outerTx.GetTx() {
+				t.Error("should be in the same transaction")
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		t.Error(err)
+	}
+
+	if err := db.EnsureTxWithPropagation(nil, nil, TxPropagation(-1), func(ctx context.Context) error {
+		return nil
+	}); err == nil {
+		t.Error("should get error here")
+	}
+}
+
+func TestTransactionCallbacks(t *testing.T) {
+	db := newMockDatabase()
+	var events []string
+
+	err := db.BeginTx(context.Background(), nil, func(tx Transaction) error {
+		tx.AfterCommit(func(ctx context.Context) {
+			events = append(events, "commit1")
+		})
+		tx.AfterRollback(func(ctx context.Context, err error) {
+			events = append(events, "rollback")
+		})
+		tx.AfterCommit(func(ctx context.Context) {
+			events = append(events, "commit2")
+		})
+		// discarded with the savepoint
+		_ = tx.Savepoint(context.Background(), func(tx Transaction) error {
+			tx.AfterCommit(func(ctx context.Context) {
+				events = append(events, "savepoint")
+			})
+			return errors.New("error")
+		})
+		if len(events) != 0 {
+			t.Error(events)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Error(err)
+	}
+	assertEqual(t, strings.Join(events, ","), "commit1,commit2")
+
+	// registered from the context of EnsureTx
+	events = nil
+	err = db.EnsureTx(context.Background(), nil, func(ctx context.Context) error {
+		return db.EnsureTx(ctx, nil, func(ctx context.Context) error {
+			tx, ok := TransactionFromContext(ctx)
+			if !ok {
+				t.Error("should be in a transaction")
+				return nil
+			}
+			tx.AfterCommit(func(ctx context.Context) {
+				events = append(events, "commit")
+			})
+			tx.AfterRollback(func(ctx context.Context, err error) {
+				events = append(events, "rollback: "+err.Error())
+			})
+			return errors.New("error")
+		})
+	})
+	if err == nil {
+		t.Error("should get error here")
+	}
+	assertEqual(t, strings.Join(events, ","), "rollback: error")
+
+	// called on commit failure
+	events = nil
+	_ = db.BeginTx(context.Background(), nil, func(tx Transaction) error {
+		tx.AfterCommit(func(ctx context.Context) {
+			events = append(events, "commit")
+		})
+		tx.AfterRollback(func(ctx context.Context, err error) {
+			events = append(events, "rollback: "+err.Error())
+		})
+		sharedMockConn.mockTx.commitError = errors.New("commit error")
+		return nil
+	})
+	assertEqual(t, strings.Join(events, ","), "rollback: commit error")
+
+	// called on panic
+	events = nil
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("should panic")
+			}
+		}()
+		_ = db.BeginTx(context.Background(), nil, func(tx Transaction) error {
+			tx.AfterRollback(func(ctx context.Context, err error) {
+				events = append(events, "rollback: "+err.Error())
+			})
+			panic("oops")
+		})
+	}()
+	assertEqual(t, strings.Join(events, ","), "rollback: panic: oops")
+
+	// discarded with the retried attempt
+	events = nil
+	db.SetTxRetryPolicy(&TxRetryPolicy{MaxAttempts: 2})
+	attempts := 0
+	_ = db.BeginTx(context.Background(), nil, func(tx Transaction) error {
+		attempts++
+		attempt := strconv.Itoa(attempts)
+		tx.AfterCommit(func(ctx context.Context) {
+			events = append(events, "commit"+attempt)
+		})
+		tx.AfterRollback(func(ctx context.Context, err error) {
+			events = append(events, "rollback"+attempt)
+		})
+		if attempts == 1 {
+			return &mockMySQLError{Number: 1213}
+		}
+		return nil
+	})
+	assertEqual(t, strings.Join(events, ","), "rollback1,commit2")
+
+	if _, ok := TransactionFromContext(context.Background()); ok {
+		t.Error("should not be in a transaction")
+	}
+	if _, ok := TransactionFromContext(nil); ok {
+		t.Error("should not be in a transaction")
+	}
+}
+
 func TestGetTxOrDBWithContext(t *testing.T) {
 	db := newMockDatabase()
 
@@ -3,7 +3,9 @@ package sqlingo
 import (
 	"context"
 	"database/sql"
-	"strconv"
+	"database/sql/driver"
+	"errors"
+	"fmt"
 	"strings"
 )
 
@@ -13,17 +15,38 @@ type updateStatus struct {
 	where       BooleanExpression
 	orderBys    []OrderBy
 	limit       *int
-	ctx         context.Context
+	returning   []Field
+	model       Model
+	// optimisticLock is only set with model
+	optimisticLock bool
+	allRows        bool
+	ctx            context.Context
 }
 
-func (d *database) Update(table Table) updateWithSet {
+func (d *database) Update(table Table) updateWithTable {
 	return updateStatus{scope: scope{Database: d, Tables: []Table{table}}}
 }
 
+type updateWithTable interface {
+	updateWithSet
+	toUpdateJoin
+}
+
+type toUpdateJoin interface {
+	Join(table Table) updateWithJoin
+	LeftJoin(table Table) updateWithJoin
+}
+
+type updateWithJoin interface {
+	On(condition BooleanExpression) updateWithTable
+}
+
 type updateWithSet interface {
 	Set(Field Field, value interface{}) updateWithSet
 	SetIf(prerequisite bool, Field Field, value interface{}) updateWithSet
+	SetModel(model Model) updateWithModel
 	Where(conditions ...BooleanExpression) updateWithWhere
+	AllRows() updateWithWhere
 	OrderBy(orderBys ...OrderBy) updateWithOrder
 	Limit(limit int) updateWithLimit
 }
@@ -31,6 +54,7 @@ type updateWithSet interface {
 type updateWithWhere interface {
 	toUpdateWithContext
 	toUpdateFinal
+	toUpdateReturning
 	OrderBy(orderBys ...OrderBy) updateWithOrder
 	Limit(limit int) updateWithLimit
 }
@@ -38,12 +62,18 @@ type updateWithWhere interface {
 type updateWithOrder interface {
 	toUpdateWithContext
 	toUpdateFinal
+	toUpdateReturning
 	Limit(limit int) updateWithLimit
 }
 
 type updateWithLimit interface {
 	toUpdateWithContext
 	toUpdateFinal
+	toUpdateReturning
+}
+
+type toUpdateReturning interface {
+	Returning(fields ...Field) returningWithFields
 }
 
 type toUpdateWithContext interface {
@@ -52,9 +82,38 @@ type toUpdateWithContext interface {
 
 type toUpdateFinal interface {
 	GetSQL() (string, error)
+	GetSQLWithArgs() (string, []interface{}, error)
 	Execute() (sql.Result, error)
 }
 
+// Join joins the table to update the rows by the columns of another table.
+// It is rendered as UPDATE ... JOIN in MySQL, UPDATE ... FROM in PostgreSQL and SQLite,
+// and UPDATE ... FROM ... JOIN in SQL Server.
+func (s updateStatus) Join(table Table) updateWithJoin {
+	return s.join("", table)
+}
+
+// LeftJoin joins the table with LEFT JOIN, which is not supported as the first join in PostgreSQL and SQLite.
+func (s updateStatus) LeftJoin(table Table) updateWithJoin {
+	return s.join("LEFT ", table)
+}
+
+func (s updateStatus) join(prefix string, table Table) updateWithJoin {
+	s.scope.lastJoin = &join{
+		previous: s.scope.lastJoin,
+		prefix:   prefix,
+		table:    table,
+	}
+	return s
+}
+
+func (s updateStatus) On(condition BooleanExpression) updateWithTable {
+	join := *s.scope.lastJoin
+	join.on = condition
+	s.scope.lastJoin = &join
+	return s
+}
+
 func (s updateStatus) Set(field Field, value interface{}) updateWithSet {
 	s.assignments = append([]assignment{}, s.assignments...)
 	s.assignments = append(s.assignments, assignment{
@@ -76,6 +135,12 @@ func (s updateStatus) Where(conditions ...BooleanExpression) updateWithWhere {
 	return s
 }
 
+// AllRows updates all the rows explicitly, which is required in safe mode if there are no conditions.
+func (s updateStatus) AllRows() updateWithWhere {
+	s.allRows = true
+	return s
+}
+
 func (s updateStatus) OrderBy(orderBys ...OrderBy) updateWithOrder {
 	s.orderBys = orderBys
 	return s
@@ -87,25 +152,117 @@ func (s updateStatus) Limit(limit int) updateWithLimit {
 }
 
 func (s updateStatus) GetSQL() (string, error) {
+	sql, _, err := s.GetSQLWithArgs()
+	return sql, err
+}
+
+func (s updateStatus) GetSQLWithArgs() (string, []interface{}, error) {
+	args := newArgList(s.scope.Database)
+	sql, err := s.buildSQL(args)
+	if err != nil {
+		return "", nil, err
+	}
+	return sql, args.getValues(), nil
+}
+
+func (s updateStatus) buildSQL(args *argList) (string, error) {
+	s.scope.args = args
+	if s.model != nil {
+		var err error
+		if s, err = s.applyModel(); err != nil {
+			return "", err
+		}
+	}
 	if len(s.assignments) == 0 {
 		return "/* UPDATE without SET clause */ DO 0", nil
 	}
+	if err := checkSafeMode(s.scope, "UPDATE", s.where, s.allRows); err != nil {
+		return "", err
+	}
 	var sb strings.Builder
 	sb.Grow(128)
 
-	sb.WriteString("UPDATE ")
-	sb.WriteString(s.scope.Tables[0].GetSQL(s.scope))
+	joins := getJoins(s.scope.lastJoin)
+	if len(joins) > 0 && (len(s.orderBys) > 0 || s.limit != nil) {
+		return "", errors.New("ORDER BY and LIMIT are not supported in UPDATE with JOIN")
+	}
 
-	assignmentsSql, err := commaAssignments(s.scope, s.assignments)
+	dialect := s.scope.getDialect()
+	top, limitSql, err := dialect.UpdateLimit(getLimit(s.limit), len(s.orderBys) > 0)
 	if err != nil {
 		return "", err
 	}
-	sb.WriteString(" SET ")
-	sb.WriteString(assignmentsSql)
+	if top != "" && len(s.orderBys) > 0 {
+		target, err := appendOrderedTarget(&sb, s.scope, top, s.where, s.orderBys)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString("UPDATE ")
+		sb.WriteString(target)
+		if err := s.appendAssignments(&sb, s.scope); err != nil {
+			return "", err
+		}
+		return sb.String(), nil
+	}
+	sb.WriteString("UPDATE")
+	sb.WriteString(top)
+	sb.WriteString(" ")
+	sb.WriteString(s.scope.Tables[0].GetSQL(s.scope))
 
-	if err := appendWhere(&sb, s.scope, s.where); err != nil {
+	where := s.where
+	if len(joins) == 0 {
+		if err := s.appendAssignments(&sb, s.scope); err != nil {
+			return "", err
+		}
+	} else {
+		switch dialect.MultiTableStyle() {
+		case MultiTableFrom, MultiTableUpdateFrom:
+			// UPDATE t SET ... FROM u WHERE <condition of joining u> AND ...
+			if joins[0].prefix != "" || joins[0].on == nil {
+				return "", fmt.Errorf("%sJOIN is not supported as the first join of UPDATE in %s", joins[0].prefix, dialect.Name())
+			}
+			// the updated columns can not be qualified by the table name
+			targetScope := s.scope
+			targetScope.lastJoin = nil
+			if err := s.appendAssignments(&sb, targetScope); err != nil {
+				return "", err
+			}
+			sb.WriteString(" FROM ")
+			sb.WriteString(joins[0].table.GetSQL(s.scope))
+			if err := appendJoins(&sb, s.scope, joins[1:], ""); err != nil {
+				return "", err
+			}
+			where = andJoinCondition(joins[0].on, where)
+		case MultiTableFromTarget:
+			// UPDATE t SET ... FROM t JOIN u ON ...
+			if err := s.appendAssignments(&sb, s.scope); err != nil {
+				return "", err
+			}
+			sb.WriteString(" FROM ")
+			sb.WriteString(s.scope.Tables[0].GetSQL(s.scope))
+			if err := appendJoins(&sb, s.scope, joins, ""); err != nil {
+				return "", err
+			}
+		default:
+			// UPDATE t JOIN u ON ... SET ...
+			if err := appendJoins(&sb, s.scope, joins, ""); err != nil {
+				return "", err
+			}
+			if err := s.appendAssignments(&sb, s.scope); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	if err := appendWhere(&sb, s.scope, where); err != nil {
+		return "", err
+	}
+
+	returningSql, err := getReturningSQL(s.scope, s.returning)
+	if err != nil {
 		return "", err
 	}
+	sb.WriteString(returningSql)
 
 	if len(s.orderBys) > 0 {
 		orderBySql, err := commaOrderBys(s.scope, s.orderBys)
@@ -116,23 +273,73 @@ func (s updateStatus) GetSQL() (string, error) {
 		sb.WriteString(orderBySql)
 	}
 
-	if s.limit != nil {
-		sb.WriteString(" LIMIT ")
-		sb.WriteString(strconv.Itoa(*s.limit))
-	}
+	sb.WriteString(limitSql)
 
 	return sb.String(), nil
 }
 
+// appendAssignments writes the SET clause, in which the updated fields are rendered in fieldScope.
+func (s updateStatus) appendAssignments(sb *strings.Builder, fieldScope scope) error {
+	sb.WriteString(" SET ")
+	for i, item := range s.assignments {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		value, _, err := getSQL(s.scope, item.value)
+		if err != nil {
+			return err
+		}
+		fieldSql, err := item.field.GetSQL(fieldScope)
+		if err != nil {
+			return err
+		}
+		sb.WriteString(fieldSql)
+		sb.WriteString(" = ")
+		sb.WriteString(value)
+	}
+	return nil
+}
+
+func (s updateStatus) Returning(fields ...Field) returningWithFields {
+	s.returning = fields
+	return returningStatus{statement: s, database: s.scope.Database}
+}
+
 func (s updateStatus) WithContext(ctx context.Context) toUpdateFinal {
 	s.ctx = ctx
 	return s
 }
 
+// Execute executes the statement, or returns a result of zero rows affected without touching the database
+// if there is nothing to set.
 func (s updateStatus) Execute() (sql.Result, error) {
-	sqlString, err := s.GetSQL()
+	if s.model != nil {
+		var err error
+		if s, err = s.applyModel(); err != nil {
+			return nil, err
+		}
+	}
+	if len(s.assignments) == 0 {
+		// nothing to update
+		return driver.RowsAffected(0), nil
+	}
+	if err := s.scope.checkShardKey(s.ctx); err != nil {
+		return nil, err
+	}
+	sqlString, args, err := s.GetSQLWithArgs()
 	if err != nil {
 		return nil, err
 	}
-	return s.scope.Database.ExecuteContext(s.ctx, sqlString)
+	result, err := s.scope.Database.ExecuteContext(s.ctx, sqlString, args...)
+	if err != nil || !s.optimisticLock {
+		return result, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return result, err
+	}
+	if rowsAffected == 0 {
+		return result, fmt.Errorf("%w: no rows of table %s updated", ErrStaleObject, s.scope.Tables[0].GetName())
+	}
+	return result, nil
 }
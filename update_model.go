@@ -0,0 +1,179 @@
+package sqlingo
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// ErrStaleObject is returned if no rows are updated with optimistic locking,
+// as the row is updated or deleted by others since it is read.
+var ErrStaleObject = errors.New("stale object")
+
+// primaryKeyTable is implemented by the generated table with a primary key.
+type primaryKeyTable interface {
+	GetPrimaryKeyFields() []Field
+}
+
+// versionTable is implemented by the generated table with a version column for optimistic locking.
+type versionTable interface {
+	GetVersionField() Field
+}
+
+type updateWithModel interface {
+	toUpdateWithContext
+	toUpdateFinal
+	toUpdateReturning
+	WithOptimisticLock() updateWithModel
+	Where(conditions ...BooleanExpression) updateWithWhere
+}
+
+// modelDiff is the model returned by Diff.
+type modelDiff struct {
+	before Model
+	after  Model
+}
+
+// Diff returns a model for SetModel, which only sets the fields changed from before to after.
+// The primary key of before is used to locate the row, so the primary key could be changed as well.
+func Diff(before, after Model) Model {
+	return modelDiff{before: before, after: after}
+}
+
+func (m modelDiff) GetTable() Table {
+	return m.after.GetTable()
+}
+
+func (m modelDiff) GetValues() []interface{} {
+	return m.after.GetValues()
+}
+
+// getChanges tells whether each field is changed.
+func (m modelDiff) getChanges() ([]bool, error) {
+	if m.before.GetTable().GetName() != m.after.GetTable().GetName() {
+		return nil, errors.New("cannot diff models of different tables")
+	}
+	beforeValues := m.before.GetValues()
+	afterValues := m.after.GetValues()
+	if len(beforeValues) != len(afterValues) {
+		return nil, fmt.Errorf("cannot diff models of %d and %d values", len(beforeValues), len(afterValues))
+	}
+	changes := make([]bool, len(afterValues))
+	for i := range afterValues {
+		changes[i] = !isSameValue(beforeValues[i], afterValues[i])
+	}
+	return changes, nil
+}
+
+func isSameValue(a, b interface{}) bool {
+	convertedA, errA := driver.DefaultParameterConverter.ConvertValue(a)
+	convertedB, errB := driver.DefaultParameterConverter.ConvertValue(b)
+	if errA != nil || errB != nil {
+		return reflect.DeepEqual(a, b)
+	}
+	switch convertedA := convertedA.(type) {
+	case time.Time:
+		convertedB, ok := convertedB.(time.Time)
+		return ok && convertedA.Equal(convertedB)
+	case []byte:
+		convertedB, ok := convertedB.([]byte)
+		return ok && (convertedA == nil) == (convertedB == nil) && bytes.Equal(convertedA, convertedB)
+	default:
+		return convertedA == convertedB
+	}
+}
+
+// SetModel sets the fields to the values of the model, or only the changed fields if the model is returned by Diff.
+// If the primary key of the table is known, the row is located by the primary key of the model,
+// which is excluded from the SET clause, and Where is optional to add more conditions.
+// If Diff finds no changes, Execute returns a result of zero rows affected without touching the database.
+func (s updateStatus) SetModel(model Model) updateWithModel {
+	s.model = model
+	return s
+}
+
+// WithOptimisticLock increments the version of the row, and updates the row only if its version is still the one of the model,
+// otherwise Execute returns ErrStaleObject. The version column is specified by the -version option of the generator.
+// The version is not checked if there is nothing to update, such as Diff finding no changes.
+func (s updateStatus) WithOptimisticLock() updateWithModel {
+	s.optimisticLock = true
+	return s
+}
+
+// applyModel adds the assignments of the model and the conditions of its primary key and version.
+func (s updateStatus) applyModel() (updateStatus, error) {
+	table := s.scope.Tables[0]
+	if s.model.GetTable().GetName() != table.GetName() {
+		return s, fmt.Errorf("cannot update table %s with model of table %s", table.GetName(), s.model.GetTable().GetName())
+	}
+
+	fields := table.GetFields()
+	values := s.model.GetValues()
+	if len(fields) != len(values) {
+		return s, fmt.Errorf("%d values of the model for %d fields", len(values), len(fields))
+	}
+	keyValues := values
+	var changes []bool
+	if diff, ok := s.model.(modelDiff); ok {
+		var err error
+		if changes, err = diff.getChanges(); err != nil {
+			return s, err
+		}
+		keyValues = diff.before.GetValues()
+	}
+
+	isPrimaryKey := make([]bool, len(fields))
+	var conditions []BooleanExpression
+	if primaryKeyTable, ok := table.(primaryKeyTable); ok {
+		for _, field := range primaryKeyTable.GetPrimaryKeyFields() {
+			index := getFieldIndex(s.scope, fields, field)
+			if index == -1 {
+				return s, fmt.Errorf("primary key field not found in table %s", table.GetName())
+			}
+			isPrimaryKey[index] = true
+			conditions = append(conditions, field.Equals(keyValues[index]))
+		}
+	}
+	if len(conditions) == 0 && s.where == nil {
+		return s, fmt.Errorf("the primary key of table %s is unknown, the rows to update should be specified by Where", table.GetName())
+	}
+
+	versionIndex := -1
+	var version NumberExpression
+	if s.optimisticLock {
+		versionTable, ok := table.(versionTable)
+		if !ok {
+			return s, fmt.Errorf("the version field of table %s is unknown", table.GetName())
+		}
+		versionField := versionTable.GetVersionField()
+		if version, ok = versionField.(NumberExpression); !ok {
+			return s, fmt.Errorf("the version field of table %s is not a number", table.GetName())
+		}
+		if versionIndex = getFieldIndex(s.scope, fields, versionField); versionIndex == -1 {
+			return s, fmt.Errorf("version field not found in table %s", table.GetName())
+		}
+		conditions = append(conditions, versionField.Equals(keyValues[versionIndex]))
+	}
+	if s.where != nil {
+		conditions = append(conditions, s.where)
+	}
+	s.where = And(conditions...)
+
+	assignments := append([]assignment{}, s.assignments...)
+	for i, field := range fields {
+		// the primary key is updated only if it's changed explicitly
+		if (changes == nil && isPrimaryKey[i]) || (changes != nil && !changes[i]) || i == versionIndex {
+			continue
+		}
+		assignments = append(assignments, assignment{field: field, value: values[i]})
+	}
+	if version != nil && len(assignments) > 0 {
+		assignments = append(assignments, assignment{field: fields[versionIndex], value: version.Add(1)})
+	}
+	s.assignments = assignments
+	s.model = nil
+	return s, nil
+}
@@ -0,0 +1,184 @@
+package sqlingo
+
+import (
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+)
+
+type tTestWithPrimaryKey struct {
+	tTest
+}
+
+func (t tTestWithPrimaryKey) GetPrimaryKeyFields() []Field {
+	return []Field{t.F1}
+}
+
+var TestWithPrimaryKey = tTestWithPrimaryKey{Test}
+
+type tVersionTest struct {
+	Table
+
+	Id      fVersionTestId
+	Name    fVersionTestName
+	Version fVersionTestVersion
+}
+
+type fVersionTestId struct{ NumberField }
+type fVersionTestName struct{ StringField }
+type fVersionTestVersion struct{ NumberField }
+
+var oVersionTest = NewTable("version_test")
+
+var VersionTest = tVersionTest{
+	Table:   oVersionTest,
+	Id:      fVersionTestId{NewNumberField(oVersionTest, "id")},
+	Name:    fVersionTestName{NewStringField(oVersionTest, "name")},
+	Version: fVersionTestVersion{NewNumberField(oVersionTest, "version")},
+}
+
+func (t tVersionTest) GetFields() []Field {
+	return []Field{t.Id, t.Name, t.Version}
+}
+
+func (t tVersionTest) GetPrimaryKeyFields() []Field {
+	return []Field{t.Id}
+}
+
+func (t tVersionTest) GetVersionField() Field {
+	return t.Version
+}
+
+type VersionTestModel struct {
+	Id      int64
+	Name    string
+	Version int32
+}
+
+func (m VersionTestModel) GetTable() Table {
+	return VersionTest
+}
+
+func (m VersionTestModel) GetValues() []interface{} {
+	return []interface{}{m.Id, m.Name, m.Version}
+}
+
+func TestUpdateSetModel(t *testing.T) {
+	db := newMockDatabase()
+
+	_, _ = db.Update(TestWithPrimaryKey).SetModel(TestModel{F1: 1, F2: "a"}).Execute()
+	assertLastSql(t, "UPDATE `test` SET `f2` = 'a' WHERE `f1` = 1")
+
+	_, _ = db.Update(TestWithPrimaryKey).SetModel(&TestModel{F1: 1, F2: "a"}).Where(Test.F2.Equals("b")).Execute()
+	assertLastSql(t, "UPDATE `test` SET `f2` = 'a' WHERE `f1` = 1 AND `f2` = 'b'")
+
+	_, _ = db.Update(Test).SetModel(TestModel{F1: 1, F2: "a"}).Where(Test.F1.Equals(2)).Execute()
+	assertLastSql(t, "UPDATE `test` SET `f1` = 1, `f2` = 'a' WHERE `f1` = 2")
+
+	// the primary key is updated only if set explicitly
+	_, _ = db.Update(TestWithPrimaryKey).Set(Test.F1, 2).SetModel(TestModel{F1: 1, F2: "a"}).Execute()
+	assertLastSql(t, "UPDATE `test` SET `f1` = 2, `f2` = 'a' WHERE `f1` = 1")
+
+	// the primary key is unknown
+	if _, err := db.Update(Test).SetModel(TestModel{F1: 1, F2: "a"}).Execute(); err == nil {
+		t.Error("should get error here")
+	}
+	if _, err := db.Update(Table1).SetModel(TestModel{F1: 1, F2: "a"}).Where(True()).Execute(); err == nil {
+		t.Error("should get error here")
+	}
+}
+
+func TestUpdateDiff(t *testing.T) {
+	db := newMockDatabase()
+
+	before := TestModel{F1: 1, F2: "a"}
+	after := before
+	sharedMockConn.lastSql = ""
+	result, err := db.Update(TestWithPrimaryKey).SetModel(Diff(before, after)).Execute()
+	if err != nil {
+		t.Error(err)
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected != 0 {
+		t.Error(rowsAffected)
+	}
+	assertLastSql(t, "")
+
+	after.F2 = "b"
+	_, _ = db.Update(TestWithPrimaryKey).SetModel(Diff(before, after)).Execute()
+	assertLastSql(t, "UPDATE `test` SET `f2` = 'b' WHERE `f1` = 1")
+
+	// the row is located by the primary key before changed
+	after.F1 = 2
+	_, _ = db.Update(TestWithPrimaryKey).SetModel(Diff(before, after)).Execute()
+	assertLastSql(t, "UPDATE `test` SET `f1` = 2, `f2` = 'b' WHERE `f1` = 1")
+
+	if _, err := db.Update(TestWithPrimaryKey).SetModel(Diff(before, AutoIncrementTestModel{})).Execute(); err == nil {
+		t.Error("should get error here")
+	}
+}
+
+func TestIsSameValue(t *testing.T) {
+	tm := time.Date(2023, 9, 6, 18, 37, 46, 0, time.UTC)
+	s := "a"
+	for _, item := range []struct {
+		a, b     interface{}
+		expected bool
+	}{
+		{1, int64(1), true},
+		{1, 2, false},
+		{"a", &s, true},
+		{nil, (*string)(nil), true},
+		{nil, "", false},
+		{tm, tm.In(time.FixedZone("UTC+8", 8*3600)), true},
+		{tm, tm.Add(time.Second), false},
+		{[]byte("a"), []byte("a"), true},
+		{[]byte{}, []byte(nil), false},
+		{struct{ a int }{1}, struct{ a int }{1}, true},
+	} {
+		if isSameValue(item.a, item.b) != item.expected {
+			t.Error(item.a, item.b, item.expected)
+		}
+	}
+}
+
+func TestUpdateWithOptimisticLock(t *testing.T) {
+	db := newMockDatabase()
+	sharedMockConn.execResult = driver.RowsAffected(1)
+	defer func() {
+		sharedMockConn.execResult = nil
+	}()
+
+	model := VersionTestModel{Id: 1, Name: "a", Version: 3}
+	if _, err := db.Update(VersionTest).SetModel(model).WithOptimisticLock().Execute(); err != nil {
+		t.Error(err)
+	}
+	assertLastSql(t, "UPDATE `version_test` SET `name` = 'a', `version` = `version` + 1 WHERE `id` = 1 AND `version` = 3")
+
+	changed := model
+	changed.Name = "b"
+	if _, err := db.Update(VersionTest).SetModel(Diff(model, changed)).WithOptimisticLock().Execute(); err != nil {
+		t.Error(err)
+	}
+	assertLastSql(t, "UPDATE `version_test` SET `name` = 'b', `version` = `version` + 1 WHERE `id` = 1 AND `version` = 3")
+
+	// nothing changed, the version is neither incremented nor checked
+	if _, err := db.Update(VersionTest).SetModel(Diff(model, model)).WithOptimisticLock().Execute(); err != nil {
+		t.Error(err)
+	}
+	assertLastSql(t, "")
+
+	sharedMockConn.execResult = driver.RowsAffected(0)
+	if _, err := db.Update(VersionTest).SetModel(model).WithOptimisticLock().Execute(); !errors.Is(err, ErrStaleObject) {
+		t.Error(err)
+	}
+	// not checked without optimistic locking
+	if _, err := db.Update(VersionTest).SetModel(model).Execute(); err != nil {
+		t.Error(err)
+	}
+	assertLastSql(t, "UPDATE `version_test` SET `name` = 'a', `version` = 3 WHERE `id` = 1")
+
+	if _, err := db.Update(TestWithPrimaryKey).SetModel(TestModel{F1: 1}).WithOptimisticLock().Execute(); err == nil {
+		t.Error("should get error here")
+	}
+}
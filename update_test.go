@@ -27,14 +27,24 @@ func TestUpdate(t *testing.T) {
 		Execute()
 	assertLastSql(t, "UPDATE `table1` SET `field1` = 10")
 
-	_, _ = db.Update(Table1).
+	// nothing to set, the database is not touched
+	result, err := db.Update(Table1).
 		SetIf(false, field1, 10).
 		Where(True()).
 		Execute()
-	assertLastSql(t, "/* UPDATE without SET clause */ DO 0")
+	if err != nil {
+		t.Error(err)
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected != 0 {
+		t.Error(rowsAffected)
+	}
+	assertLastSql(t, "")
 
 	_, _ = db.Update(Table1).Limit(3).Execute()
-	assertLastSql(t, "/* UPDATE without SET clause */ DO 0")
+	assertLastSql(t, "")
+	if sqlString, _ := db.Update(Table1).Limit(3).GetSQL(); sqlString != "/* UPDATE without SET clause */ DO 0" {
+		t.Error(sqlString)
+	}
 
 	errExp := &expression{
 		builder: func(scope scope) (string, error) {
@@ -71,3 +81,51 @@ func TestUpdate(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestUpdateJoin(t *testing.T) {
+	db := newMockDatabase()
+
+	_, _ = db.Update(Table1).Join(table2).On(field1.Equals(field3)).
+		Set(field2, field3).
+		Where(field3.GreaterThan(1)).
+		Execute()
+	assertLastSql(t, "UPDATE `table1` JOIN `table2` ON `table1`.`field1` = `table2`.`field3`"+
+		" SET `table1`.`field2` = `table2`.`field3` WHERE `table2`.`field3` > 1")
+
+	_, _ = db.Update(Table1).LeftJoin(table2).On(field1.Equals(field3)).
+		Set(field2, 0).
+		Where(field3.IsNull()).
+		Execute()
+	assertLastSql(t, "UPDATE `table1` LEFT JOIN `table2` ON `table1`.`field1` = `table2`.`field3`"+
+		" SET `table1`.`field2` = 0 WHERE `table2`.`field3` IS NULL")
+
+	if _, err := db.Update(Table1).Join(table2).On(field1.Equals(field3)).
+		Set(field2, field3).
+		Limit(1).
+		Execute(); err == nil {
+		t.Error("should get error here")
+	}
+
+	postgres := newParameterizedMockDatabase(dialectPostgres)
+	_, _ = postgres.Update(Table1).Join(table2).On(field1.Equals(field3)).
+		Set(field2, field3.Add(1)).
+		Where(field3.GreaterThan(2)).
+		Returning(field1).
+		FetchAll()
+	assertLastSql(t, `UPDATE "table1" SET "field2" = "table2"."field3" + $1 FROM "table2"`+
+		` WHERE "table1"."field1" = "table2"."field3" AND "table2"."field3" > $2 RETURNING "table1"."field1"`)
+	assertLastArgs(t, int64(1), int64(2))
+
+	sqlite := UseWithDialect(nil, dialectSqlite3)
+	sql, _ := sqlite.Update(Table1).Join(table2).On(field1.Equals(field3)).Set(field2, field3).Where(True()).GetSQL()
+	assertEqual(t, sql, `UPDATE "table1" SET "field2" = "table2"."field3" FROM "table2" WHERE "table1"."field1" = "table2"."field3"`)
+
+	if _, err := sqlite.Update(Table1).LeftJoin(table2).On(field1.Equals(field3)).Set(field2, field3).Where(True()).GetSQL(); err == nil {
+		t.Error("should get error here")
+	}
+
+	mssql := UseWithDialect(nil, dialectMSSQL)
+	sql, _ = mssql.Update(Table1).Join(table2).On(field1.Equals(field3)).Set(field2, field3).Where(field3.GreaterThan(1)).GetSQL()
+	assertEqual(t, sql, "UPDATE [table1] SET [table1].[field2] = [table2].[field3] FROM [table1]"+
+		" JOIN [table2] ON [table1].[field1] = [table2].[field3] WHERE [table2].[field3] > 1")
+}
@@ -1,6 +1,10 @@
 package sqlingo
 
-import "testing"
+import (
+	"database/sql/driver"
+	"reflect"
+	"testing"
+)
 
 var dummyMySQLScope = scope{Database: &database{dialect: dialectMySQL}}
 
@@ -32,3 +36,11 @@ func assertError(t *testing.T, value interface{}) {
 		t.Errorf("value [%v] generated [%s] expected error", value, generatedSql)
 	}
 }
+
+func assertLastArgs(t *testing.T, expectedArgs ...driver.Value) {
+	t.Helper()
+	if !reflect.DeepEqual(sharedMockConn.lastArgs, expectedArgs) {
+		t.Errorf("last args %v expected %v", sharedMockConn.lastArgs, expectedArgs)
+	}
+	sharedMockConn.lastArgs = nil
+}